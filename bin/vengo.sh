@@ -29,6 +29,16 @@ alias vengo=${VENGO_HOME}/bin/vengo
 
 # VenGO activate script
 function vengo_activate {
+    if [ -z "$1" ]; then
+        # use the environment of the nearest .vengo file
+        local project
+        project="$("$VENGO_HOME/bin/vengo" which)" || project=""
+        if [ -z "$project" ]; then
+            vengo lsenvs
+            return 1
+        fi
+        set -- "$project"
+    fi
     if [ -n "$1" ]; then
         environment="$1"
         if [ "$environment" = "" ]; then
@@ -40,13 +50,15 @@ function vengo_activate {
             return 1
         fi
         shift
+        local pre_activate_script=""
+        local post_activate_script=""
         for i in "$@"; do
             case $i in
                 --pre-activate=*)
-                    pre_activate_script=$(echo "$1" | sed 's/[-a-zA-Z0-9]*=//')
+                    pre_activate_script="${i#*=}"
                 ;;
                 --post-activate=*)
-                    post_activate_script=$(echo "$i" | sed 's/[-a-zA-Z0-9]*=//')
+                    post_activate_script="${i#*=}"
                 ;;
                 -h|--help)
                     vengo_activate_help
@@ -62,6 +74,9 @@ function vengo_activate {
 
         check_environment_exixtance $environment || return 1
         activate="$VENGO_HOME/$environment/bin/activate"
+        if [ -n "$ZSH_VERSION" ] && [ -f "$activate.zsh" ]; then
+            activate="$activate.zsh"
+        fi
         if [ ! -f "$activate" ]; then
             echo "VenGO: Environment '$VENGO_HOME/$environment' doesn't contains an activate script." >&2
             echo "`Ok`suggestion`Reset`: check the integrity of the environments with 'vengo lsenvs'" >&2
@@ -75,14 +90,18 @@ function vengo_activate {
             unset -f deactivate >/dev/null 2>&1
         fi
 
-        if [ -z ${pre_activate_script+x} ] && [ "$pre_activate_script" != "" ]; then
-            pre_activate_script "$environment"
+        # restore the environment Go version if it has been archived
+        "$VENGO_HOME/bin/vengo" cache restore -e "$environment" >/dev/null || return 1
+
+        # the environment and global hooks are run by the activate script
+        if [ -n "$pre_activate_script" ]; then
+            source "$pre_activate_script" "$environment"
         fi
 
         source "$activate"
 
-        if [ -z ${post_activate_script+x} ] && [ "$post_activate_script" != "" ]; then
-            post_activate_script "$environment"
+        if [ -n "$post_activate_script" ]; then
+            source "$post_activate_script" "$environment"
         fi
 
         return 0
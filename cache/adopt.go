@@ -0,0 +1,156 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+// Register an existing Go installation (GOROOT) into the cache. By default
+// the installation is referenced using symbolic links, if copy is true, the
+// whole GOROOT is copied into the cache instead
+func Adopt(log *logger.Logger, goroot, name string, copy bool, f ...bool) (*Metadata, error) {
+	goroot, err := filepath.Abs(goroot)
+	if err != nil {
+		return nil, err
+	}
+	goBin := filepath.Join(goroot, "bin", "go")
+	if runtime.GOOS == "windows" {
+		goBin += ".exe"
+	}
+	if _, err := os.Stat(goBin); err != nil {
+		return nil, fmt.Errorf("%s doesn't looks like a valid GOROOT: %v", goroot, err)
+	}
+	goVersion, err := detectGoVersion(goroot, goBin)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = fmt.Sprintf("system-%s", strings.TrimPrefix(goVersion, "go"))
+	}
+	if Exists(name) && !(len(f) > 0 && f[0]) {
+		return nil, fmt.Errorf(
+			"%s already exists in the cache, use --force to replace it", name)
+	}
+
+	destination := filepath.Join(CacheDirectory(), name)
+	os.RemoveAll(destination)
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		return nil, err
+	}
+	var step *logger.Step
+	if copy {
+		step = log.Step(fmt.Sprintf("Copying %s into the cache", goroot))
+		err = copyGoroot(goroot, destination)
+	} else {
+		step = log.Step(fmt.Sprintf("Linking %s into the cache", goroot))
+		err = linkGoroot(goroot, destination)
+	}
+	if err != nil {
+		step.Fail(err)
+		os.RemoveAll(destination)
+		return nil, err
+	}
+	step.Ok()
+
+	step = log.Step("Generating manifest")
+	if err := generateManifest(name); err != nil {
+		step.Fail(err)
+		os.RemoveAll(destination)
+		return nil, err
+	}
+	step.Ok()
+
+	source := "adopted"
+	if copy {
+		source = "adopted-copy"
+	}
+	metadata := NewMetadata(name, func(m *Metadata) {
+		m.Source = source
+		m.Origin = goroot
+		m.GoVersion = goVersion
+	})
+	if err := metadata.Save(); err != nil {
+		os.RemoveAll(destination)
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// detect the Go version of a GOROOT from it's VERSION file or asking to
+// the go tool itself if the file is not present
+func detectGoVersion(goroot, goBin string) (string, error) {
+	if data, err := ioutil.ReadFile(filepath.Join(goroot, "VERSION")); err == nil {
+		if ver := strings.TrimSpace(strings.Split(string(data), "\n")[0]); ver != "" {
+			return ver, nil
+		}
+	}
+	cmd := exec.Command(goBin, "version")
+	cmd.Env = append(os.Environ(), fmt.Sprintf("GOROOT=%s", goroot))
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("can't detect Go version in %s: %v", goroot, err)
+	}
+	// go version go1.4.2 linux/amd64
+	fields := strings.Fields(string(out))
+	if len(fields) < 3 {
+		return "", errors.New("can't parse 'go version' output")
+	}
+	return fields[2], nil
+}
+
+// link every top level entry of the GOROOT into destination
+func linkGoroot(goroot, destination string) error {
+	entries, err := ioutil.ReadDir(goroot)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err := os.Symlink(
+			filepath.Join(goroot, entry.Name()),
+			filepath.Join(destination, entry.Name()),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copy the whole GOROOT contents into destination
+func copyGoroot(goroot, destination string) error {
+	out, err := exec.Command(
+		"cp", "-R", goroot+string(filepath.Separator)+".", destination,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", out)
+	}
+	return nil
+}
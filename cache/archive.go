@@ -0,0 +1,239 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+const archivedFile = ".vengo-archived"
+
+// return the directory where archived Go versions are stored
+func ArchivesDirectory() string {
+	return filepath.Join(CacheDirectory(), "archive")
+}
+
+// Compress a cached Go version into a single archive inside the cache and
+// leave an stub in it's place, returns back the number of bytes saved
+func Archive(log *logger.Logger, ver string) (int64, error) {
+	if InSystemCache(ver) {
+		return 0, systemCacheError(ver)
+	}
+	if !Exists(ver) {
+		return 0, fmt.Errorf("%s is not a Go installed version", ver)
+	}
+	if IsArchived(ver) {
+		return 0, fmt.Errorf("%s is already archived", ver)
+	}
+	if err := os.MkdirAll(ArchivesDirectory(), 0755); err != nil {
+		return 0, err
+	}
+	step := log.Step(fmt.Sprintf("Archiving %s", ver))
+	versionPath := VersionPath(ver)
+	archive := filepath.Join(ArchivesDirectory(), ver+".tar.gz")
+	size, err := writeArchive(versionPath, archive)
+	if err != nil {
+		step.Fail(err)
+		os.Remove(archive)
+		return 0, err
+	}
+	info, err := os.Stat(archive)
+	if err != nil {
+		step.Fail(err)
+		return 0, err
+	}
+
+	// the stub keeps the metadata so custom names are still recognized
+	metadata, _ := ioutil.ReadFile(filepath.Join(versionPath, metadataFile))
+	if err := os.RemoveAll(versionPath); err != nil {
+		step.Fail(err)
+		return 0, err
+	}
+	if err := os.MkdirAll(versionPath, 0755); err != nil {
+		step.Fail(err)
+		return 0, err
+	}
+	if metadata != nil {
+		ioutil.WriteFile(filepath.Join(versionPath, metadataFile), metadata, 0644)
+	}
+	err = ioutil.WriteFile(
+		filepath.Join(versionPath, archivedFile), []byte(archive+"\n"), 0644)
+	if err != nil {
+		step.Fail(err)
+		return 0, err
+	}
+	step.Ok()
+	return size - info.Size(), nil
+}
+
+// checks if the given cached Go version is archived
+func IsArchived(ver string) bool {
+	_, err := os.Stat(filepath.Join(VersionPath(ver), archivedFile))
+	return err == nil
+}
+
+// Restore an archived Go version into the cache, it does nothing if the
+// version is not archived. Archived versions of the system cache can't be
+// restored, they have to be restored by the admin that archived them
+func Restore(log *logger.Logger, ver string) error {
+	if !IsArchived(ver) {
+		return nil
+	}
+	if InSystemCache(ver) {
+		return systemCacheError(ver)
+	}
+	versionPath := VersionPath(ver)
+	data, err := ioutil.ReadFile(filepath.Join(versionPath, archivedFile))
+	if err != nil {
+		return err
+	}
+	archive := strings.TrimSpace(string(data))
+	step := log.Step(fmt.Sprintf("Restoring archived %s", ver))
+	restoring := filepath.Join(filepath.Dir(versionPath), "."+ver+".restoring")
+	os.RemoveAll(restoring)
+	if err := readArchive(archive, restoring); err != nil {
+		step.Fail(err)
+		os.RemoveAll(restoring)
+		return err
+	}
+	if err := os.RemoveAll(versionPath); err != nil {
+		step.Fail(err)
+		return err
+	}
+	if err := os.Rename(restoring, versionPath); err != nil {
+		step.Fail(err)
+		return err
+	}
+	os.Remove(archive)
+	step.Ok()
+	return nil
+}
+
+// write the contents of the given directory into a tar.gz archive, returns
+// the size of the archived files
+func writeArchive(dir, archive string) (int64, error) {
+	file, err := os.Create(archive)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	gw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gw)
+	var size int64
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil || name == "." {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		n, err := io.Copy(tw, f)
+		size += n
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return size, gw.Close()
+}
+
+// extract a tar.gz archive written by writeArchive into the given directory
+func readArchive(archive, dir string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.FileMode(hdr.Mode).Perm())
+		case tar.TypeSymlink:
+			err = os.Symlink(hdr.Linkname, target)
+		case tar.TypeReg, tar.TypeRegA:
+			var f *os.File
+			f, err = os.OpenFile(
+				target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err == nil {
+				_, err = io.Copy(f, tr)
+				f.Close()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
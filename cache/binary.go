@@ -25,31 +25,46 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/mcuadros/go-version"
 )
 
 // Download an specific version of Golang binary files
-func CacheDownloadBinary(ver string, f ...bool) error {
+func CacheDownloadBinary(log *logger.Logger, ver string, f ...bool) error {
+	return CacheDownloadPlatformBinary(log, ver, runtime.GOOS, runtime.GOARCH, f...)
+}
+
+// Download an specific version of Golang binary files for the given
+// operating system and architecture
+func CacheDownloadPlatformBinary(log *logger.Logger, ver, goos, goarch string, f ...bool) error {
+	force := len(f) > 0 && f[0]
+	return cacheDownloadBinary(log, ver, goos, goarch, force, SignatureIfAvailable)
+}
+
+// download the binary files of the given version and platform verifying
+// their signature with the given policy
+func cacheDownloadBinary(log *logger.Logger, ver, goos, goarch string, force bool, policy SignaturePolicy) error {
 	numeric_ver := ver
-	ver = GetBinaryVersion(ver)
+	ver = GetPlatformBinaryVersion(ver, goos, goarch)
 	expected_sha1, err := Checksum(ver)
 	if err != nil {
 		return err
 	}
 
-	if !Exists(ver) || (len(f) > 0 && f[0]) {
+	if !Exists(ver) || force {
 		url := fmt.Sprintf(
 			"https://storage.googleapis.com/golang/go%s.tar.gz", ver)
 		if version.Compare(version.Normalize(numeric_ver), "1.2.2", "<") {
 			url = fmt.Sprintf(
 				"https://go.googlecode.com/files/go%s.tar.gz", ver)
 		}
-		if runtime.GOOS == "windows" {
+		if goos == "windows" {
 			url = strings.Replace(url, ".tar.gz", ".zip", -1)
 		}
-		if err := downloadAndExtract(ver, url, expected_sha1); err != nil {
+		if err := downloadAndExtract(log, ver, url, expected_sha1, policy); err != nil {
 			return err
 		}
 		if err := generateManifest(ver); err != nil {
@@ -60,3 +75,97 @@ func CacheDownloadBinary(ver string, f ...bool) error {
 
 	return nil
 }
+
+// return back the binary string version for downloads in the given
+// operating system and architecture, the host platform is delegated to
+// GetBinaryVersion so OS X releases are detected properly
+func GetPlatformBinaryVersion(version, goos, goarch string) string {
+	if goos == runtime.GOOS && goarch == runtime.GOARCH {
+		return GetBinaryVersion(version)
+	}
+	binary := fmt.Sprintf("%s.%s-%s", version, goos, goarch)
+	if goos == "darwin" {
+		for _, osx := range []string{"osx10.8", "osx10.6"} {
+			if _, err := Checksum(binary + "-" + osx); err == nil {
+				return binary + "-" + osx
+			}
+		}
+	}
+	return binary
+}
+
+// return back the platform string (GOOS/GOARCH) of the host
+func HostPlatform() string {
+	return fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// checks if the given cached version can run on the host, returns back the
+// platform the version has been installed for
+func RunsOnHost(ver string) (string, bool) {
+	metadata, err := LoadMetadata(ver)
+	if err != nil || metadata.Platform == "" {
+		return HostPlatform(), true
+	}
+	if metadata.Platform == HostPlatform() {
+		return metadata.Platform, true
+	}
+	// 64 bits x86 hosts are able to run 32 bits x86 binaries
+	return metadata.Platform, runtime.GOARCH == "amd64" &&
+		metadata.Platform == fmt.Sprintf("%s/386", runtime.GOOS)
+}
+
+// installation source for the already compiled binary tarballs
+type binarySource struct{}
+
+func init() {
+	RegisterSource(binarySource{})
+}
+
+func (binarySource) Name() string { return "binary" }
+
+func (binarySource) Describe(r *InstallRequest) string { return "binary" }
+
+// platforms without a known checksum are rejected before downloading
+// anything, there are no official binaries for them
+func (binarySource) Resolve(r *InstallRequest) error {
+	goos, goarch := r.Platform()
+	r.Name = GetPlatformBinaryVersion(r.Version, goos, goarch)
+	if _, err := Checksum(r.Name); err != nil {
+		return fmt.Errorf(
+			"there are no %s/%s binaries of Go %s, supported platforms are %s",
+			goos, goarch, r.Version, strings.Join(BinaryPlatforms(r.Version), ", "))
+	}
+	return nil
+}
+
+// return back the platforms (GOOS/GOARCH) with binaries of the given version
+func BinaryPlatforms(ver string) []string {
+	platforms, seen := []string{}, map[string]bool{}
+	prefix := strings.TrimPrefix(ver, "go") + "."
+	for name := range checksums {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		fields := strings.Split(strings.TrimPrefix(name, prefix), "-")
+		if len(fields) < 2 || strings.Contains(fields[0], ".") {
+			continue
+		}
+		platform := fields[0] + "/" + fields[1]
+		if !seen[platform] {
+			seen[platform] = true
+			platforms = append(platforms, platform)
+		}
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+func (binarySource) Fetch(r *InstallRequest) error {
+	goos, goarch := r.Platform()
+	return cacheDownloadBinary(r.Log, r.Version, goos, goarch, r.Force, r.Signature)
+}
+
+// binaries are already compiled, the manifest is generated while fetching
+func (binarySource) Build(r *InstallRequest) error {
+	return nil
+}
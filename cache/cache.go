@@ -29,11 +29,30 @@ import (
 	"strings"
 )
 
-// return a list of installed go versions
+// return a list of installed go versions, the versions in the system cache
+// are included after the ones installed in the user cache
 func GetInstalled(tags, sources, binaries []string) ([]string, error) {
-	files, err := filepath.Glob(filepath.Join(CacheDirectory(), "*"))
+	versions, err := installedIn(CacheDirectory(), tags, sources, binaries)
+	if err != nil {
+		return nil, err
+	}
+	system, err := installedIn(SystemCacheDirectory(), tags, sources, binaries)
+	if err != nil {
+		return nil, err
+	}
+	for _, ver := range system {
+		if InSystemCache(ver) {
+			versions = append(versions, ver)
+		}
+	}
+
+	return versions, nil
+}
+
+// return the installed go versions in the given cache directory
+func installedIn(directory string, tags, sources, binaries []string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(directory, "*"))
 	if err != nil {
-		fmt.Println("while getting installed versions:", err)
 		return nil, err
 	}
 	versions := []string{}
@@ -42,11 +61,11 @@ func GetInstalled(tags, sources, binaries []string) ([]string, error) {
 		if filename != "mercurial" && filename != "logs" && filename != "git" {
 			stat, err := os.Stat(file)
 			if err != nil {
-				fmt.Println("while getting installed versions:", err)
 				return nil, err
 			}
 			if stat.IsDir() {
-				if isValidVersion(filename, tags, sources, binaries) {
+				if isValidVersion(filename, tags, sources, binaries) ||
+					HasMetadata(filename) {
 					versions = append(versions, filename)
 				}
 			}
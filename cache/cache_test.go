@@ -1,8 +1,10 @@
 package cache_test
 
 import (
-	"fmt"
+	"errors"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
@@ -10,6 +12,7 @@ import (
 	"runtime"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/DamnWidget/VenGO/cache"
 )
@@ -26,9 +29,6 @@ func runningOnTravis() bool {
 
 var _ = Describe("Cache", func() {
 
-	// disable log output
-	cache.Output = ioutil.Discard
-
 	Describe("ExpandUser returns valid path depending on platform", func() {
 		var re *regexp.Regexp
 
@@ -122,6 +122,154 @@ var _ = Describe("Cache", func() {
 		})
 	})
 
+	Describe("InstallSource", func() {
+		It("Should register the built in installation sources", func() {
+			Expect(cache.Sources()).To(Equal([]string{"binary", "git", "repository", "tarball"}))
+			source, err := cache.LookupSource("git")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(source.Name()).To(Equal("git"))
+			_, err = cache.LookupSource("none")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should resolve the name used in the cache", func() {
+			source, _ := cache.LookupSource("git")
+			request := &cache.InstallRequest{Version: "1.4"}
+			Expect(source.Resolve(request)).To(Succeed())
+			Expect(request.Name).To(Equal("go1.4"))
+		})
+
+		It("Should reject sources without signature when it is required", func() {
+			for _, name := range []string{"git", "repository"} {
+				source, _ := cache.LookupSource(name)
+				request := &cache.InstallRequest{
+					Version: "1.4", Repo: "https://example.com/go",
+					Signature: cache.SignatureRequired}
+				Expect(source.Resolve(request)).ToNot(Succeed())
+			}
+			source, _ := cache.LookupSource("tarball")
+			request := &cache.InstallRequest{
+				Version: "1.4", Signature: cache.SignatureRequired}
+			Expect(source.Resolve(request)).To(Succeed())
+		})
+
+		It("Should reject binaries for platforms without official releases", func() {
+			source, _ := cache.LookupSource("binary")
+			request := &cache.InstallRequest{Version: "1.4", GOOS: "linux", GOARCH: "arm"}
+			err := source.Resolve(request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("linux/amd64"))
+			Expect(cache.BinaryPlatforms("1.3")).To(ContainElement("windows/amd64"))
+			Expect(cache.BinaryPlatforms("1.4")).ToNot(ContainElement("linux/arm"))
+		})
+
+		It("Should resolve binaries for non native platforms", func() {
+			source, _ := cache.LookupSource("binary")
+			request := &cache.InstallRequest{Version: "1.3.3", GOOS: "linux", GOARCH: "386"}
+			Expect(source.Resolve(request)).To(Succeed())
+			Expect(request.Name).To(Equal("1.3.3.linux-386"))
+			if runtime.GOOS != "darwin" {
+				Expect(cache.GetPlatformBinaryVersion("1.2", "darwin", "amd64")).To(Equal("1.2.darwin-amd64-osx10.8"))
+			}
+		})
+
+		It("Should infer the source of versions without metadata", func() {
+			Expect(cache.InstalledSource("go1.4")).To(Equal("git"))
+			Expect(cache.InstalledSource("1.3.3")).To(Equal("tarball"))
+			Expect(cache.InstalledSource("1.3.3.linux-amd64")).To(Equal("binary"))
+			Expect(cache.InstalledSource("custom")).To(Equal("unknown"))
+		})
+	})
+
+	Describe("VerifySignature", func() {
+		BeforeEach(func() {
+			os.Setenv("VENGO_KEYRING", filepath.Join(os.TempDir(), "vengo-missing-keyring.gpg"))
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("VENGO_KEYRING")
+		})
+
+		It("Should skip the verification if there is no keyring", func() {
+			fingerprint, err := cache.VerifySignature(nil,
+				"http://localhost/go.tar.gz", []byte{}, cache.SignatureIfAvailable)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fingerprint).To(BeEmpty())
+		})
+
+		It("Should use the bundled keyring if there is no other one", func() {
+			os.Unsetenv("VENGO_KEYRING")
+			Expect(cache.Keyring()).To(Equal(cache.BundledKeyring()))
+		})
+
+		It("Should fail if the signature is required and there is no keyring", func() {
+			_, err := cache.VerifySignature(nil,
+				"http://localhost/go.tar.gz", []byte{}, cache.SignatureRequired)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("HTTPConfig", func() {
+		var requests int
+		var server *httptest.Server
+		var config = cache.NewHTTPConfig(func(c *cache.HTTPConfig) {
+			c.Backoff = time.Millisecond
+			c.Netrc = filepath.Join(os.TempDir(), "vengo-test-netrc")
+		})
+
+		BeforeEach(func() {
+			requests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				switch r.URL.Path {
+				case "/flaky":
+					if requests < 3 {
+						w.WriteHeader(http.StatusBadGateway)
+						return
+					}
+				case "/private":
+					if user, password, ok := r.BasicAuth(); !ok || user != "vengo" || password != "secret" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+				case "/missing":
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte("go"))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.Remove(config.Netrc)
+		})
+
+		It("Should retry server errors with backoff", func() {
+			data, err := config.Download(server.URL + "/flaky")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("go"))
+			Expect(requests).To(Equal(3))
+		})
+
+		It("Should not retry missing resources", func() {
+			_, err := config.Download(server.URL + "/missing")
+
+			Expect(cache.IsNotFound(err)).To(BeTrue())
+			Expect(requests).To(Equal(1))
+		})
+
+		It("Should use the netrc credentials for the host", func() {
+			netrc := "machine 127.0.0.1\n  login vengo\n  password secret\n"
+			Expect(ioutil.WriteFile(config.Netrc, []byte(netrc), 0600)).To(Succeed())
+			data, err := config.Download(server.URL + "/private")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("go"))
+		})
+	})
+
 	if !runningOnTravis() {
 		Describe("Exists works as expected", func() {
 			Context("Used in a file that actually exists", func() {
@@ -179,17 +327,27 @@ var _ = Describe("Cache", func() {
 		Describe("CacheDonwloadGit works as expected", func() {
 			Context("Passing a non valid Go version", func() {
 				It("Should fail and give back a descriptive error", func() {
-					err := cache.CacheDownloadGit("20.0")
+					err := cache.CacheDownloadGit(nil, "20.0")
 					Expect(err).To(HaveOccurred())
-					Expect(err).To(Equal(fmt.Errorf(
-						"20.0 doesn't seems to be a valid Go release\n"),
-					))
+					Expect(err).To(MatchError(
+						"20.0 doesn't seems to be a valid Go release\n"))
+					Expect(cache.IsVersionUnknown(err)).To(BeTrue())
+				})
+			})
+
+			Context("Without git installed", func() {
+				It("Should give back ErrGitMissing", func() {
+					path := os.Getenv("PATH")
+					os.Setenv("PATH", "")
+					defer os.Setenv("PATH", path)
+					err := cache.CacheDownloadGit(nil, "1.4")
+					Expect(err).To(Equal(cache.ErrGitMissing))
 				})
 			})
 
 			Context("Passing a valid Go version", func() {
 				It("Should clone it into the cache directory", func() {
-					err := cache.CacheDownloadGit("go1.1")
+					err := cache.CacheDownloadGit(nil, "go1.1")
 					Expect(err).ToNot(HaveOccurred())
 					_, err = os.Stat(filepath.Join(cache.CacheDirectory(), "go1.1"))
 					Expect(err).NotTo(HaveOccurred())
@@ -200,7 +358,7 @@ var _ = Describe("Cache", func() {
 
 			Context("Passing a valid Go version with no go prefix", func() {
 				It("Should clone it into the cache directory", func() {
-					err := cache.CacheDownloadGit("1")
+					err := cache.CacheDownloadGit(nil, "1")
 					Expect(err).ToNot(HaveOccurred())
 					_, err = os.Stat(filepath.Join(cache.CacheDirectory(), "go1"))
 					Expect(err).NotTo(HaveOccurred())
@@ -210,6 +368,273 @@ var _ = Describe("Cache", func() {
 			})
 		})
 
+		Describe("Metadata", func() {
+			AfterEach(func() {
+				os.RemoveAll(filepath.Join(cache.CacheDirectory(), "test-fork"))
+			})
+
+			It("Should be saved and loaded back from the cache", func() {
+				Expect(os.MkdirAll(filepath.Join(cache.CacheDirectory(), "test-fork"), 0755)).To(Succeed())
+				Expect(cache.HasMetadata("test-fork")).To(BeFalse())
+				m := cache.NewMetadata("test-fork", func(m *cache.Metadata) {
+					m.Source = "repository"
+					m.Repository = "/tmp/go"
+					m.Commit = "0000000000000000000000000000000000000000"
+				})
+
+				Expect(m.Save()).To(Succeed())
+				Expect(cache.HasMetadata("test-fork")).To(BeTrue())
+				loaded, err := cache.LoadMetadata("test-fork")
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(loaded.Name).To(Equal("test-fork"))
+				Expect(loaded.Source).To(Equal("repository"))
+				Expect(loaded.Repository).To(Equal("/tmp/go"))
+				Expect(loaded.Commit).To(Equal(m.Commit))
+			})
+
+			It("Should report versions installed for other platforms", func() {
+				Expect(os.MkdirAll(filepath.Join(cache.CacheDirectory(), "test-platform"), 0755)).To(Succeed())
+				defer os.RemoveAll(filepath.Join(cache.CacheDirectory(), "test-platform"))
+				Expect(cache.NewMetadata("test-platform", func(m *cache.Metadata) {
+					m.Platform = "plan9/arm"
+				}).Save()).To(Succeed())
+
+				platform, ok := cache.RunsOnHost("test-platform")
+				Expect(ok).To(BeFalse())
+				Expect(platform).To(Equal("plan9/arm"))
+			})
+
+			It("Should make GetInstalled recognize custom names", func() {
+				Expect(os.MkdirAll(filepath.Join(cache.CacheDirectory(), "test-fork"), 0755)).To(Succeed())
+				Expect(cache.NewMetadata("test-fork").Save()).To(Succeed())
+				installed, err := cache.GetInstalled(nil, nil, nil)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(installed).To(ContainElement("test-fork"))
+			})
+		})
+
+		Describe("SystemCacheDirectory", func() {
+			var system string
+
+			BeforeEach(func() {
+				var err error
+				system, err = ioutil.TempDir("", "VenGO-system")
+				Expect(err).ToNot(HaveOccurred())
+				os.Setenv("VENGO_SYSTEM_CACHE", system)
+				Expect(os.MkdirAll(filepath.Join(system, "test-system"), 0755)).To(Succeed())
+				data := []byte(`{"name": "test-system", "source": "binary"}`)
+				Expect(ioutil.WriteFile(
+					filepath.Join(system, "test-system", ".vengo-metadata"), data, 0644)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				os.Setenv("VENGO_SYSTEM_CACHE", "")
+				os.RemoveAll(system)
+				os.RemoveAll(filepath.Join(cache.CacheDirectory(), "test-system"))
+			})
+
+			It("Should provide the versions that are not in the user cache", func() {
+				Expect(cache.SystemCacheDirectory()).To(Equal(system))
+				Expect(cache.InSystemCache("test-system")).To(BeTrue())
+				Expect(cache.VersionPath("test-system")).To(Equal(filepath.Join(system, "test-system")))
+				Expect(cache.InstalledSource("test-system")).To(Equal("binary"))
+				installed, err := cache.GetInstalled(nil, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(installed).To(ContainElement("test-system"))
+			})
+
+			It("Should prefer the versions in the user cache", func() {
+				Expect(os.MkdirAll(filepath.Join(cache.CacheDirectory(), "test-system"), 0755)).To(Succeed())
+				Expect(cache.InSystemCache("test-system")).To(BeFalse())
+				Expect(cache.VersionPath("test-system")).To(Equal(
+					filepath.Join(cache.CacheDirectory(), "test-system")))
+			})
+
+			It("Should refuse to archive the system versions", func() {
+				_, err := cache.Archive(nil, "test-system")
+				Expect(errors.Is(err, cache.ErrSystemCache)).To(BeTrue())
+				_, err = os.Stat(filepath.Join(system, "test-system", ".vengo-metadata"))
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("Should refuse to restore archived system versions", func() {
+				stub := filepath.Join(system, "test-system", ".vengo-archived")
+				Expect(ioutil.WriteFile(stub, []byte("/nowhere.tar.gz\n"), 0644)).To(Succeed())
+				Expect(cache.IsArchived("test-system")).To(BeTrue())
+				err := cache.Restore(nil, "test-system")
+				Expect(errors.Is(err, cache.ErrSystemCache)).To(BeTrue())
+				_, err = os.Stat(stub)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Describe("Dedupe", func() {
+			var versions = []string{"test-dedupe1", "test-dedupe2"}
+
+			BeforeEach(func() {
+				for _, ver := range versions {
+					dir := filepath.Join(cache.CacheDirectory(), ver, "src")
+					Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(dir, "same.go"), []byte("package same"), 0644)).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(dir, "other.go"), []byte(ver), 0644)).To(Succeed())
+					Expect(cache.NewMetadata(ver).Save()).To(Succeed())
+				}
+			})
+
+			AfterEach(func() {
+				for _, ver := range versions {
+					os.RemoveAll(filepath.Join(cache.CacheDirectory(), ver))
+				}
+				os.RemoveAll(cache.StoreDirectory())
+			})
+
+			It("Should hardlink identical files and report the bytes saved", func() {
+				saved, err := cache.Dedupe(nil, versions...)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(saved).To(Equal(int64(len("package same"))))
+				first, err := os.Stat(filepath.Join(cache.CacheDirectory(), versions[0], "src", "same.go"))
+				Expect(err).ToNot(HaveOccurred())
+				second, err := os.Stat(filepath.Join(cache.CacheDirectory(), versions[1], "src", "same.go"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(os.SameFile(first, second)).To(BeTrue())
+			})
+
+			It("Should prune the store when the versions are removed", func() {
+				_, err := cache.Dedupe(nil, versions...)
+				Expect(err).ToNot(HaveOccurred())
+				for _, ver := range versions {
+					os.RemoveAll(filepath.Join(cache.CacheDirectory(), ver))
+				}
+
+				saved, err := cache.Dedupe(nil, []string{}...)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(saved).To(BeZero())
+				stored, _ := filepath.Glob(filepath.Join(cache.StoreDirectory(), "*", "*"))
+				if runtime.GOOS != "windows" {
+					Expect(stored).To(BeEmpty())
+				}
+			})
+		})
+
+		Describe("Adopt", func() {
+			var goroot string
+			var versionPath = filepath.Join(cache.CacheDirectory(), "test-adopt")
+
+			BeforeEach(func() {
+				var err error
+				goroot, err = ioutil.TempDir("", "vengo-goroot")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(os.MkdirAll(filepath.Join(goroot, "bin"), 0755)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(goroot, "src", "fmt"), 0755)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(goroot, "bin", "go"), []byte("binary"), 0755)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(goroot, "src", "fmt", "print.go"), []byte("package fmt"), 0644)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(goroot, "VERSION"), []byte("go1.4.2"), 0644)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(goroot)
+				os.RemoveAll(versionPath)
+			})
+
+			It("Should copy the GOROOT into the cache", func() {
+				metadata, err := cache.Adopt(nil, goroot, "test-adopt", true)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metadata.Source).To(Equal("adopted-copy"))
+				Expect(metadata.GoVersion).To(Equal("go1.4.2"))
+				info, err := os.Lstat(filepath.Join(versionPath, "src"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.IsDir()).To(BeTrue())
+				data, err := ioutil.ReadFile(filepath.Join(versionPath, "src", "fmt", "print.go"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal("package fmt"))
+				Expect(cache.CheckManifestIntegrity(
+					filepath.Join(versionPath, ".vengo-manifest"))).To(Succeed())
+			})
+
+			It("Should reference the GOROOT using symbolic links", func() {
+				metadata, err := cache.Adopt(nil, goroot, "test-adopt", false)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metadata.Source).To(Equal("adopted"))
+				Expect(metadata.Origin).To(Equal(goroot))
+				for _, entry := range []string{"bin", "src", "VERSION"} {
+					link, err := os.Readlink(filepath.Join(versionPath, entry))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(link).To(Equal(filepath.Join(goroot, entry)))
+				}
+				Expect(cache.CheckManifestIntegrity(
+					filepath.Join(versionPath, ".vengo-manifest"))).To(Succeed())
+			})
+
+			It("Should refuse directories that are not a GOROOT", func() {
+				Expect(os.Remove(filepath.Join(goroot, "bin", "go"))).To(Succeed())
+				_, err := cache.Adopt(nil, goroot, "test-adopt", false)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("doesn't looks like a valid GOROOT"))
+				Expect(cache.Exists("test-adopt")).To(BeFalse())
+			})
+		})
+
+		Describe("PathReplacer", func() {
+			It("Should only replace whole paths", func() {
+				r := cache.NewPathReplacer("/x/cache", "/y/cache")
+				Expect(r.Replace("/x/cache\n/x/cache/go1.4 \"/x/cache\"")).To(Equal(
+					"/y/cache\n/y/cache/go1.4 \"/y/cache\""))
+				Expect(r.Replace("/x/cache2/go1.4 /x/cache.old /z/x/cache")).To(Equal(
+					"/x/cache2/go1.4 /x/cache.old /z/x/cache"))
+			})
+		})
+
+		Describe("Archive", func() {
+			var ver = "test-archive"
+			var versionPath = filepath.Join(cache.CacheDirectory(), ver)
+
+			BeforeEach(func() {
+				Expect(os.MkdirAll(filepath.Join(versionPath, "bin"), 0755)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(versionPath, "bin", "go"), []byte("binary"), 0755)).To(Succeed())
+				Expect(os.Symlink("go", filepath.Join(versionPath, "bin", "golink"))).To(Succeed())
+				Expect(cache.NewMetadata(ver).Save()).To(Succeed())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(versionPath)
+				os.RemoveAll(cache.ArchivesDirectory())
+			})
+
+			It("Should leave an stub that is still listed as installed", func() {
+				_, err := cache.Archive(nil, ver)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cache.IsArchived(ver)).To(BeTrue())
+				_, err = os.Stat(filepath.Join(versionPath, "bin"))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+				installed, err := cache.GetInstalled(nil, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(installed).To(ContainElement(ver))
+			})
+
+			It("Should restore the archived contents", func() {
+				_, err := cache.Archive(nil, ver)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cache.Restore(nil, ver)).To(Succeed())
+				Expect(cache.IsArchived(ver)).To(BeFalse())
+				data, err := ioutil.ReadFile(filepath.Join(versionPath, "bin", "go"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal("binary"))
+				link, err := os.Readlink(filepath.Join(versionPath, "bin", "golink"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(link).To(Equal("go"))
+				_, err = os.Stat(filepath.Join(cache.ArchivesDirectory(), ver+".tar.gz"))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+
 		Describe("AlreadyCompiled", func() {
 			It("Shoudl return true if the source has been compiled", func() {
 				os.MkdirAll(filepath.Join(cache.CacheDirectory(), "test1", "go", "bin"), 0755)
@@ -219,10 +644,10 @@ var _ = Describe("Cache", func() {
 				filename = filepath.Join(cache.CacheDirectory(), "test1", ".vengo-manifest")
 
 				Expect(ioutil.WriteFile(filename, []byte{}, 0644)).To(Succeed())
-				Expect(cache.AlreadyCompiled("test1")).To(BeTrue())
+				Expect(cache.AlreadyCompiled(nil, "test1")).To(BeTrue())
 				os.RemoveAll(filepath.Join(cache.CacheDirectory(), "test1"))
 
-				Expect(cache.AlreadyCompiled("test1")).To(BeFalse())
+				Expect(cache.AlreadyCompiled(nil, "test1")).To(BeFalse())
 			})
 		})
 
@@ -230,17 +655,17 @@ var _ = Describe("Cache", func() {
 			Describe("CacheDownload works as expected", func() {
 				Context("Passing a non valid Go version", func() {
 					It("Should fail and give back a descriptive error", func() {
-						err := cache.CacheDownload("1.0")
+						err := cache.CacheDownload(nil, "1.0")
 						Expect(err).To(HaveOccurred())
-						Expect(err).To(Equal(fmt.Errorf(
-							"1.0 is not a VenGO supported version you must donwload and compile it yourself"),
-						))
+						Expect(err).To(MatchError(
+							"1.0 is not a VenGO supported version you must donwload and compile it yourself"))
+						Expect(cache.IsVersionUnknown(err)).To(BeTrue())
 					})
 				})
 
 				Context("Passing a valid Go version", func() {
 					It("Should download and extract a valid tar.gz file", func() {
-						Expect(cache.CacheDownload("1.2.2")).To(Succeed())
+						Expect(cache.CacheDownload(nil, "1.2.2")).To(Succeed())
 
 						_, err := os.Stat(filepath.Join(cache.CacheDirectory(), "1.2.2"))
 						Expect(err).NotTo(HaveOccurred())
@@ -251,7 +676,7 @@ var _ = Describe("Cache", func() {
 
 				Context("Passing an old Go version", func() {
 					It("Should donwload and extract a valid tar.gz file", func() {
-						Expect(cache.CacheDownload("1.1.1")).To(Succeed())
+						Expect(cache.CacheDownload(nil, "1.1.1")).To(Succeed())
 
 						_, err := os.Stat(filepath.Join(cache.CacheDirectory(), "1.1.1"))
 						Expect(err).NotTo(HaveOccurred())
@@ -265,7 +690,7 @@ var _ = Describe("Cache", func() {
 
 				Context("Passing a valid Go version", func() {
 					It("Should download and extract a valid tar.gz file", func() {
-						Expect(cache.CacheDownloadBinary("1.2.2")).To(Succeed())
+						Expect(cache.CacheDownloadBinary(nil, "1.2.2")).To(Succeed())
 
 						binary := cache.GetBinaryVersion("1.2.2")
 						_, err := os.Stat(
@@ -279,7 +704,7 @@ var _ = Describe("Cache", func() {
 
 				Context("Passing an old Go version", func() {
 					It("Should donwload and extract a valid tar.gz file", func() {
-						Expect(cache.CacheDownloadBinary("1.2.1")).To(Succeed())
+						Expect(cache.CacheDownloadBinary(nil, "1.2.1")).To(Succeed())
 
 						binary := cache.GetBinaryVersion("1.2.1")
 						_, err := os.Stat(
@@ -295,7 +720,7 @@ var _ = Describe("Cache", func() {
 			Describe("Compile works as expected", func() {
 				Context("Giving a non existent version", func() {
 					It("Shuld return an error", func() {
-						err := cache.Compile("1.0", false, false)
+						err := cache.Compile(nil, "1.0", false, false)
 						Expect(err).To(HaveOccurred())
 						Expect(os.IsNotExist(err)).To(BeTrue())
 					})
@@ -303,10 +728,10 @@ var _ = Describe("Cache", func() {
 
 				Context("Giving an existent version", func() {
 					It("Shoudl recompile it", func() {
-						err := cache.CacheDownloadGit("1.3.3")
+						err := cache.CacheDownloadGit(nil, "1.3.3")
 
 						Expect(err).ToNot(HaveOccurred())
-						Expect(cache.Compile("1.3.3", false, false)).To(Succeed())
+						Expect(cache.Compile(nil, "1.3.3", false, false)).To(Succeed())
 					})
 				})
 			})
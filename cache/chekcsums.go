@@ -20,8 +20,6 @@
 
 package cache
 
-import "fmt"
-
 var checksums map[string]string = map[string]string{
 	// sources
 
@@ -186,7 +184,7 @@ func Checksum(version string) (string, error) {
 	if sha1, ok := checksums[version]; ok {
 		return sha1, nil
 	}
-	return "", fmt.Errorf("%s is not a VenGO supported version you must donwload and compile it yourself", version)
+	return "", unknownVersion("%s is not a VenGO supported version you must donwload and compile it yourself", version)
 }
 
 // return back the list of downloaable sources
@@ -0,0 +1,146 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+// return the content addressed store used to deduplicate files between
+// Go versions
+func StoreDirectory() string {
+	return filepath.Join(CacheDirectory(), "store")
+}
+
+// Replace identical files across the given cached versions (all the cached
+// versions with a manifest if none is given) with hardlinks to the content
+// addressed store, returns back the number of bytes saved
+func Dedupe(log *logger.Logger, versions ...string) (int64, error) {
+	if len(versions) == 0 {
+		var err error
+		if versions, err = ManifestedVersions(); err != nil {
+			return 0, err
+		}
+	}
+	var saved int64
+	for _, ver := range versions {
+		if !Exists(ver) {
+			return saved, fmt.Errorf("%s is not a Go installed version", ver)
+		}
+		step := log.Step(fmt.Sprintf("Deduplicating %s", ver))
+		s, err := dedupeVersion(ver)
+		saved += s
+		if err != nil {
+			step.Fail(err)
+			return saved, err
+		}
+		step.Ok()
+	}
+	return saved, pruneStore()
+}
+
+// hardlink every regular file of the version into the store
+func dedupeVersion(ver string) (int64, error) {
+	var saved int64
+	err := filepath.Walk(
+		filepath.Join(CacheDirectory(), ver),
+		func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() || info.Size() == 0 {
+				return nil
+			}
+			if info.Name() == ".vengo-manifest" || info.Name() == metadataFile {
+				return nil
+			}
+			sum, err := fileSha1(path, info)
+			if err != nil {
+				return err
+			}
+			// files with the same contents but different permissions can't
+			// share the same inode so the mode is part of the address
+			stored := filepath.Join(
+				StoreDirectory(), sum[:2], fmt.Sprintf("%s-%o", sum, info.Mode().Perm()))
+			storedInfo, err := os.Stat(stored)
+			if err != nil {
+				if !os.IsNotExist(err) {
+					return err
+				}
+				if err := os.MkdirAll(filepath.Dir(stored), 0755); err != nil {
+					return err
+				}
+				return os.Link(path, stored)
+			}
+			if os.SameFile(info, storedInfo) {
+				return nil
+			}
+			tmp := path + ".vengo-dedupe"
+			if err := os.Link(stored, tmp); err != nil {
+				return err
+			}
+			if err := os.Rename(tmp, path); err != nil {
+				os.Remove(tmp)
+				return err
+			}
+			saved += info.Size()
+			return nil
+		},
+	)
+	return saved, err
+}
+
+// remove files from the store that are not used by any version anymore
+func pruneStore() error {
+	if _, err := os.Stat(StoreDirectory()); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(StoreDirectory(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && linkCount(path, info) == 1 {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// return a list of the versions in the cache that have a manifest
+func ManifestedVersions() ([]string, error) {
+	files, err := ioutil.ReadDir(CacheDirectory())
+	if err != nil {
+		return nil, err
+	}
+	versions := []string{}
+	for _, file := range files {
+		manifest := filepath.Join(CacheDirectory(), file.Name(), ".vengo-manifest")
+		if _, err := os.Stat(manifest); err == nil {
+			versions = append(versions, file.Name())
+		}
+	}
+	return versions, nil
+}
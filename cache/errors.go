@@ -0,0 +1,55 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// error returned when the git command line is not installed
+var ErrGitMissing = errors.New("Git is not installed on this system")
+
+// error returned when a Go version is not known by the installation source,
+// the returned errors keep their own message and match it with errors.Is
+var ErrVersionUnknown = errors.New("unknown Go version")
+
+type versionError struct {
+	message string
+}
+
+// create a new error that matches ErrVersionUnknown
+func unknownVersion(format string, args ...interface{}) error {
+	return &versionError{fmt.Sprintf(format, args...)}
+}
+
+func (e *versionError) Error() string {
+	return e.message
+}
+
+func (e *versionError) Is(target error) bool {
+	return target == ErrVersionUnknown
+}
+
+// determine if the given error is caused by an unknown Go version
+func IsVersionUnknown(err error) bool {
+	return errors.Is(err, ErrVersionUnknown)
+}
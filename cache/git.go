@@ -22,40 +22,70 @@ package cache
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
-	"github.com/DamnWidget/VenGO/utils"
+	"github.com/DamnWidget/VenGO/logger"
 )
 
 const REPO = "https://github.com/golang/go.git"
 
+// guards the shared git clone and the git logs from concurrent installs
+var gitMutex sync.Mutex
+
 var TARGET = filepath.Join(CacheDirectory(), "git")
 var logFile *os.File
 
 // get git tags from git repo
-func Tags() []string {
-	return getVersionTags()
+func Tags(log *logger.Logger) ([]string, error) {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+	return getVersionTags(log)
+}
+
+// get git tags from the remote git repo without cloning or updating it
+func RemoteTags(log *logger.Logger) ([]string, error) {
+	out, err := exec.Command("git", "ls-remote", "--tags", REPO).Output()
+	if err != nil {
+		return nil, err
+	}
+	tags := []string{"go"}
+	for _, line := range strings.Split(string(out), "\n") {
+		// <sha1>\trefs/tags/go1.4.2, annotated tags are listed twice
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.HasSuffix(fields[1], "^{}") {
+			continue
+		}
+		tag := strings.TrimPrefix(fields[1], "refs/tags/")
+		if !strings.Contains(tag, "weekly") {
+			tags = append(tags, tag)
+		}
+	}
+	log.Debug(fmt.Sprintf("%d tags listed from %s", len(tags)-1, REPO))
+	sort.Strings(tags)
+	return tags, nil
 }
 
 // Download git repository and clone the given version
-func CacheDownloadGit(ver string, f ...bool) error {
-	logFile = openGitLogs()
-	availableVersions := getVersionTags()
-	if availableVersions == nil {
-		log.Fatal("Fatal error, exiting...")
+func CacheDownloadGit(log *logger.Logger, ver string, f ...bool) error {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+	logFile = openGitLogs(log)
+	availableVersions, err := getVersionTags(log)
+	if err != nil {
+		return err
 	}
-	ver = normalizeVersion(ver)
+	ver = NormalizeVersion(ver)
 
 	index := lookupVersion(ver, availableVersions)
 	if index == -1 {
-		return fmt.Errorf("%s doesn't seems to be a valid Go release\n", ver)
+		return unknownVersion("%s doesn't seems to be a valid Go release\n", ver)
 	}
-	if err := cloneSource(); err != nil {
+	if err := cloneSource(log); err != nil {
 		return err
 	}
 
@@ -64,16 +94,17 @@ func CacheDownloadGit(ver string, f ...bool) error {
 		force = true
 	}
 	if exists, err := SourceExists(ver); !force && err != nil {
-		log.Fatal(err)
+		return err
 	} else if !exists || force {
-		if err := copySource(ver); err != nil {
+		if err := copySource(log, ver); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func normalizeVersion(ver string) string {
+// return the name used in the cache for the given git version
+func NormalizeVersion(ver string) string {
 	if !strings.HasPrefix(ver, "go") {
 		if strings.HasPrefix(ver, "1") {
 			return fmt.Sprintf("go%s", ver)
@@ -88,89 +119,82 @@ func normalizeVersion(ver string) string {
 	return ver
 }
 
-func checkSource(tag string) error {
-	fmt.Fprintf(Output, "Checking %s... ", tag)
+func checkSource(log *logger.Logger, tag string) error {
+	step := log.Step(fmt.Sprintf("Checking %s", tag))
 	out, err := exec.Command("hg", "pull", "-R", TARGET).CombinedOutput()
 	if err != nil {
-		fmt.Fprintln(Output, utils.Fail("✖"))
+		step.Fail(err)
 		return err
 	}
-	fmt.Fprintln(Output, utils.Ok("✔"))
-	logOutput(out)
+	step.Ok()
+	logOutput(log, out)
 	return nil
 }
 
-func cloneSource() error {
+func cloneSource(log *logger.Logger) error {
 	// check if git command line is installed
 	if _, err := exec.LookPath("git"); err != nil {
-		log.Fatal("Git is not installed on this system.")
+		return ErrGitMissing
 	}
 
 	if GitExists() {
-		return pull()
+		return pull(log)
 	}
-	fmt.Fprint(Output, "Cloning Go sources from Github... ")
+	step := log.Step("Cloning Go sources from Github")
 
 	out, err := exec.Command("git", "clone", REPO, TARGET).CombinedOutput()
 	if err != nil {
-		fmt.Println(Output, utils.Fail("✖"))
+		step.Fail(err)
 		return err
 	}
-	fmt.Fprintln(Output, utils.Ok("✔"))
-	logOutput(out)
+	step.Ok()
+	logOutput(log, out)
 	return nil
 }
 
-func copySource(ver string) error {
-	var out []byte
-	fmt.Fprint(Output, "Copying source... ")
+func copySource(log *logger.Logger, ver string) error {
+	step := log.Step("Copying source", "version", ver)
 	destination := filepath.Join(CacheDirectory(), ver)
 	os.RemoveAll(destination)
-	curr, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-	defer func() {
-		exec.Command("git", "checkout", "master").Run()
-		os.Chdir(curr)
-	}()
-	os.Chdir(TARGET)
+	defer gitCommand("checkout", "master").Run()
 	if ver != "go" && ver != "tip" {
-		out, err := exec.Command("git", "checkout", ver).CombinedOutput()
-		log.Println(string(out), err)
+		out, err := gitCommand("checkout", ver).CombinedOutput()
+		logOutput(log, out)
 		if err != nil {
-			fmt.Fprintln(Output, utils.Fail("✖"))
+			step.Fail(err)
 			return fmt.Errorf("%s", out)
 		}
 	}
-	out, err = exec.Command("cp", "-R", "../git", destination).CombinedOutput()
+	out, err := exec.Command("cp", "-R", TARGET, destination).CombinedOutput()
 	if err != nil {
-		fmt.Fprintln(Output, utils.Fail("✖"))
+		step.Fail(err)
 		return err
 	}
-	fmt.Fprintln(Output, utils.Ok("✔"))
-	logOutput(out)
+	step.Ok()
+	logOutput(log, out)
 	return nil
 }
 
-func pull() error {
-	curr, err := os.Getwd()
+func pull(log *logger.Logger) error {
+	step := log.Step("Pulling Go sources from Github")
+	out, err := gitCommand("pull").CombinedOutput()
 	if err != nil {
+		step.Fail(err)
 		return err
 	}
-	defer os.Chdir(curr)
-	os.Chdir(TARGET)
-	fmt.Fprintf(Output, "Pulling Go sources from Github... ")
-	out, err := exec.Command("git", "pull").CombinedOutput()
-	if err != nil {
-		fmt.Println(Output, utils.Fail("✖"))
-		return err
-	}
-	fmt.Fprintln(Output, utils.Ok("✔"))
-	logOutput(out)
+	step.Ok()
+	logOutput(log, out)
 	return nil
 }
 
+// create a git command that runs in the shared clone, the working directory
+// of the process is never changed so concurrent installs are safe
+func gitCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = TARGET
+	return cmd
+}
+
 func lookupVersion(ver string, availableVersions []string) (index int) {
 	if ver == "go" || ver == "tip" {
 		return 0xBEDEAD
@@ -190,14 +214,7 @@ func lookupVersion(ver string, availableVersions []string) (index int) {
 
 func getVersionTagsFromGitRepo() ([]string, error) {
 	tags := []string{}
-	curr, err := os.Getwd()
-	if err != nil {
-		return nil, err
-	}
-	os.Chdir(TARGET)
-	defer os.Chdir(curr)
-
-	out, err := exec.Command("git", "tag").Output()
+	out, err := gitCommand("tag").Output()
 	if err != nil {
 		return nil, err
 	}
@@ -209,22 +226,22 @@ func getVersionTagsFromGitRepo() ([]string, error) {
 	return tags, nil
 }
 
-func getVersionTags() (tags []string) {
-	tags = append(tags, "go")
-	if err := cloneSource(); err != nil {
-		log.Fatal(err)
+func getVersionTags(log *logger.Logger) ([]string, error) {
+	tags := []string{"go"}
+	if err := cloneSource(log); err != nil {
+		return nil, err
 	}
 
 	newTags, err := getVersionTagsFromGitRepo()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	tags = append(tags, newTags...)
 	sort.Strings(tags)
-	return tags
+	return tags, nil
 }
 
-func openGitLogs() *os.File {
+func openGitLogs(log *logger.Logger) *os.File {
 	logsDir := filepath.Join(CacheDirectory(), "logs")
 	openFlags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
 	file, err := os.OpenFile(filepath.Join(logsDir, "git-go.log"), openFlags, 0644)
@@ -233,8 +250,8 @@ func openGitLogs() *os.File {
 			os.MkdirAll(logsDir, 0755)
 			file, err = os.OpenFile(filepath.Join(logsDir, "git-go.log"), openFlags, 0644)
 			if err != nil {
-				fmt.Fprintf(Output, "error: can't open log file to write: %s\n", err)
-				fmt.Fprintf(Output, "this is a non fatal error, ignoring...")
+				log.Warn(fmt.Sprintf(
+					"can't open log file to write: %s, ignoring...", err))
 				return nil
 			}
 		}
@@ -242,6 +259,34 @@ func openGitLogs() *os.File {
 	return file
 }
 
-func logOutput(out []byte) {
+func logOutput(log *logger.Logger, out []byte) {
 	logFile.Write(out)
+	log.LineWriter(logger.Debug).Write(out)
+}
+
+// installation source for the official git repository
+type gitSource struct{}
+
+func init() {
+	RegisterSource(gitSource{})
+}
+
+func (gitSource) Name() string { return "git" }
+
+func (gitSource) Describe(r *InstallRequest) string { return "github" }
+
+func (s gitSource) Resolve(r *InstallRequest) error {
+	if r.Signature == SignatureRequired {
+		return unsignedError(s, r)
+	}
+	r.Name = NormalizeVersion(r.Version)
+	return nil
+}
+
+func (gitSource) Fetch(r *InstallRequest) error {
+	return CacheDownloadGit(r.Log, r.Version, r.Force)
+}
+
+func (gitSource) Build(r *InstallRequest) error {
+	return Compile(r.Log, r.Version, r.Verbose, r.NoCGO, r.BootStrap)
 }
@@ -0,0 +1,275 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// HTTP client configuration, it can be changed using the environment
+// variables shown below (proxies are configured with the standard
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables)
+//
+//	VENGO_CONNECT_TIMEOUT  timeout to establish connections (30s)
+//	VENGO_READ_TIMEOUT     timeout between reads of a response (60s)
+//	VENGO_HTTP_RETRIES     retries on server errors or reset connections (3)
+//	VENGO_CA_BUNDLE        extra PEM CA bundles separated by the OS list separator
+//	NETRC                  netrc file used for basic auth (~/.netrc)
+type HTTPConfig struct {
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	Retries        int
+	Backoff        time.Duration
+	CABundles      []string
+	Netrc          string
+}
+
+// create a new HTTPConfig using the environment and return it's address
+func NewHTTPConfig(options ...func(c *HTTPConfig)) *HTTPConfig {
+	config := &HTTPConfig{
+		ConnectTimeout: envDuration("VENGO_CONNECT_TIMEOUT", 30*time.Second),
+		ReadTimeout:    envDuration("VENGO_READ_TIMEOUT", 60*time.Second),
+		Retries:        3,
+		Backoff:        time.Second,
+		Netrc:          ExpandUser(filepath.Join("~", ".netrc")),
+	}
+	if retries, err := strconv.Atoi(os.Getenv("VENGO_HTTP_RETRIES")); err == nil {
+		config.Retries = retries
+	}
+	if bundles := os.Getenv("VENGO_CA_BUNDLE"); bundles != "" {
+		config.CABundles = filepath.SplitList(bundles)
+	}
+	if netrc := os.Getenv("NETRC"); netrc != "" {
+		config.Netrc = netrc
+	}
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// error returned when the server response is not successful
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s: %s", e.URL, e.Status)
+}
+
+// checks if the given error is an HTTPError because the resource is missing
+func IsNotFound(err error) bool {
+	httpErr, ok := err.(*HTTPError)
+	return ok && (httpErr.StatusCode == 404 || httpErr.StatusCode == 400)
+}
+
+// Download the given url using a client built from the given configuration
+// returns back the response body, server errors and reset connections are
+// retried with exponential backoff
+func (c *HTTPConfig) Download(rawurl string) ([]byte, error) {
+	client, err := c.client()
+	if err != nil {
+		return nil, err
+	}
+	backoff := c.Backoff
+	for attempt := 0; ; attempt++ {
+		data, err := c.get(client, rawurl)
+		if err == nil || attempt >= c.Retries || !retryable(err) {
+			return data, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// perform a single GET request reading the whole response body
+func (c *HTTPConfig) get(client *http.Client, rawurl string) ([]byte, error) {
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if login, password, ok := netrcAuth(c.Netrc, req.URL.Hostname()); ok {
+		req.SetBasicAuth(login, password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, &HTTPError{rawurl, resp.StatusCode, resp.Status}
+	}
+	buf := new(bytes.Buffer)
+	if _, err := io.Copy(buf, resp.Body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// build the http client for the configuration
+func (c *HTTPConfig) client() (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+	if len(c.CABundles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, bundle := range c.CABundles {
+			pem, err := ioutil.ReadFile(bundle)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", bundle)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+	dialer := &net.Dialer{Timeout: c.ConnectTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &timeoutConn{conn, c.ReadTimeout}, nil
+		},
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   c.ConnectTimeout,
+		ResponseHeaderTimeout: c.ReadTimeout,
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// connection that fails if a read takes longer than the timeout
+type timeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	if c.timeout > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	return c.Conn.Read(b)
+}
+
+// checks if a failed request should be retried
+func retryable(err error) bool {
+	if httpErr, ok := err.(*HTTPError); ok {
+		return httpErr.StatusCode >= 500
+	}
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// look for the login and password of the given host in a netrc file
+func netrcAuth(netrc, host string) (string, string, bool) {
+	file, err := os.Open(netrc)
+	if err != nil {
+		return "", "", false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Split(bufio.ScanWords)
+	var machine, login, password string
+	found, inMacro := false, false
+	for scanner.Scan() {
+		token := scanner.Text()
+		if inMacro {
+			// macro definitions are not supported, skip until next machine
+			if token != "machine" && token != "default" {
+				continue
+			}
+			inMacro = false
+		}
+		switch token {
+		case "machine", "default":
+			if found {
+				return login, password, true
+			}
+			machine, login, password = "", "", ""
+			if token == "default" {
+				machine = host
+			} else if scanner.Scan() {
+				machine = scanner.Text()
+			}
+			found = machine == host
+		case "login", "password", "account":
+			if !scanner.Scan() {
+				break
+			}
+			if token == "login" {
+				login = scanner.Text()
+			} else if token == "password" {
+				password = scanner.Text()
+			}
+		case "macdef":
+			inMacro = true
+		}
+	}
+	return login, password, found
+}
+
+// read a duration from the environment, plain numbers are seconds
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return duration
+	}
+	return fallback
+}
+
+// download the given url using the configuration from the environment
+func httpDownload(rawurl string) ([]byte, error) {
+	return NewHTTPConfig().Download(rawurl)
+}
@@ -0,0 +1,153 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+// installation request passed to the installation sources
+type InstallRequest struct {
+	Version   string
+	Name      string
+	Force     bool
+	Verbose   bool
+	NoCGO     bool
+	BootStrap string
+	Repo      string
+	Ref       string
+	GOOS      string
+	GOARCH    string
+	Signature SignaturePolicy
+	Log       *logger.Logger
+
+	// sources can fill the metadata that will be recorded after the build
+	Metadata *Metadata
+}
+
+// installation sources resolve, fetch and build Go versions into the cache
+type InstallSource interface {
+	// unique name of the source, it is recorded in the installation metadata
+	Name() string
+	// human readable description of where the version is installed from
+	Describe(r *InstallRequest) string
+	// validate the request and set the name used in the cache
+	Resolve(r *InstallRequest) error
+	// download the version into the cache
+	Fetch(r *InstallRequest) error
+	// compile the fetched version and generate it's manifest
+	Build(r *InstallRequest) error
+}
+
+var installSources = map[string]InstallSource{}
+
+// register an installation source, sources are usually registered in the
+// init function of the file that implements them
+func RegisterSource(source InstallSource) {
+	installSources[source.Name()] = source
+}
+
+// return back the installation source registered with the given name
+func LookupSource(name string) (InstallSource, error) {
+	source, ok := installSources[name]
+	if !ok {
+		return nil, fmt.Errorf(
+			"%s is not a valid installation source (%s)",
+			name, strings.Join(Sources(), ", "))
+	}
+	return source, nil
+}
+
+// return back the sorted names of the registered installation sources
+func Sources() []string {
+	names := []string{}
+	for name := range installSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// error returned while resolving requests that require a signature from
+// sources that download versions without one
+func unsignedError(source InstallSource, r *InstallRequest) error {
+	return fmt.Errorf(
+		"versions installed from %s have no signature to verify",
+		source.Describe(r))
+}
+
+// return back the operating system and architecture the request installs
+// for, the host platform is used by default
+func (r *InstallRequest) Platform() (string, string) {
+	goos, goarch := r.GOOS, r.GOARCH
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	return goos, goarch
+}
+
+// record the installation metadata of a built request
+func SaveInstallMetadata(source InstallSource, r *InstallRequest) (*Metadata, error) {
+	metadata := r.Metadata
+	if metadata == nil {
+		// keep the data recorded while fetching (e.g. the signature)
+		var err error
+		if metadata, err = LoadMetadata(r.Name); err != nil {
+			metadata = NewMetadata(r.Name)
+		}
+		metadata.Installed = time.Now()
+	}
+	metadata.Source = source.Name()
+	goos, goarch := r.Platform()
+	metadata.Platform = fmt.Sprintf("%s/%s", goos, goarch)
+	return metadata, metadata.Save()
+}
+
+// return back the name of the source a cached version was installed from,
+// versions installed before the metadata was recorded are inferred by name
+func InstalledSource(ver string) string {
+	if metadata, err := LoadMetadata(ver); err == nil && metadata.Source != "" {
+		return metadata.Source
+	}
+	for _, binary := range AvailableBinaries() {
+		if binary == ver {
+			return "binary"
+		}
+	}
+	for _, source := range AvailableSources() {
+		if source == ver {
+			return "tarball"
+		}
+	}
+	if ver == "tip" || strings.HasPrefix(ver, "go") || strings.HasPrefix(ver, "release") {
+		return "git"
+	}
+	return "unknown"
+}
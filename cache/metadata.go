@@ -0,0 +1,83 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const metadataFile = ".vengo-metadata"
+
+// installation metadata structure
+type Metadata struct {
+	Name       string    `json:"name"`
+	Source     string    `json:"source"`
+	Repository string    `json:"repository,omitempty"`
+	Reference  string    `json:"reference,omitempty"`
+	Commit     string    `json:"commit,omitempty"`
+	Origin     string    `json:"origin,omitempty"`
+	GoVersion  string    `json:"go_version,omitempty"`
+	Platform   string    `json:"platform,omitempty"`
+	Signature  string    `json:"signature,omitempty"`
+	Installed  time.Time `json:"installed"`
+}
+
+// creates a new Metadata for the given cache name and return it's address
+func NewMetadata(name string, options ...func(m *Metadata)) *Metadata {
+	m := &Metadata{Name: name, Installed: time.Now()}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// load the metadata of an installed version from the cache
+func LoadMetadata(ver string) (*Metadata, error) {
+	data, err := ioutil.ReadFile(filepath.Join(VersionPath(ver), metadataFile))
+	if err != nil {
+		return nil, err
+	}
+	m := new(Metadata)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// checks if the given cached version has installation metadata
+func HasMetadata(ver string) bool {
+	_, err := os.Stat(filepath.Join(VersionPath(ver), metadataFile))
+	return err == nil
+}
+
+// write the metadata into it's version directory in the cache
+func (m *Metadata) Save() error {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(
+		filepath.Join(CacheDirectory(), m.Name, metadataFile), data, 0644)
+}
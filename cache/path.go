@@ -22,30 +22,35 @@ package cache
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"crypto/sha1"
 	"fmt"
 	"io"
-	"log"
-	"net/http"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
 
-	"github.com/DamnWidget/VenGO/utils"
+	"github.com/DamnWidget/VenGO/logger"
 )
 
-var Output io.Writer = os.Stdout
+var VenGO_PATH = VenGOHome()
 
-var VenGO_PATH = filepath.Join(ExpandUser("~"), ".VenGO")
+// return back the VenGO home where the environments live, ~/.VenGO or the
+// value of the VENGO_HOME environment variable if it is set
+func VenGOHome() string {
+	if VENGO_HOME := os.Getenv("VENGO_HOME"); VENGO_HOME != "" {
+		return VENGO_HOME
+	}
+	return filepath.Join(ExpandUser("~"), ".VenGO")
+}
 
 // Expand the user home tilde to the right user home path
 func ExpandUser(path string) string {
 	u, err := user.Current()
 	if err != nil {
-		fmt.Fprintln(Output, "Can't get current user:", err)
 		return path
 	}
 	return strings.Replace(path, "~", u.HomeDir, -1)
@@ -58,28 +63,19 @@ func Exists(ver string) bool {
 }
 
 // download and extract the given file checking the given sha1 signature
-func downloadAndExtract(ver, url, expected_sha1 string) error {
-	resp, err := http.Get(url)
+func downloadAndExtract(log *logger.Logger, ver, url, expected_sha1 string, policy SignaturePolicy) error {
+	step := log.Step(fmt.Sprintf("downloading Go%s from %s", ver, url))
+	data, err := httpDownload(url)
 	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode != 200 {
-		if resp.StatusCode == 400 {
-			log.Fatalf("Version %s can't be found!\n", ver)
+		step.Fail(err)
+		if IsNotFound(err) {
+			return fmt.Errorf("Version %s can't be found! %v", ver, err)
 		}
-		return fmt.Errorf("%s", resp.Status)
-	}
-	defer resp.Body.Close()
-
-	fmt.Fprintf(Output, "downloading Go%s from %s ", ver, url)
-	buf := new(bytes.Buffer)
-	size, err := io.Copy(buf, resp.Body)
-	if err != nil {
-		fmt.Fprintln(Output, utils.Fail("✖"))
 		return err
 	}
-	fmt.Fprintln(Output, utils.Ok("✔"))
+	buf := bytes.NewBuffer(data)
+	size := len(data)
+	step.Ok()
 
 	pkg_sha1 := fmt.Sprintf("%x", sha1.Sum(buf.Bytes()))
 	if pkg_sha1 != expected_sha1 {
@@ -88,45 +84,60 @@ func downloadAndExtract(ver, url, expected_sha1 string) error {
 			expected_sha1, pkg_sha1,
 		)
 	}
-	fmt.Fprintf(Output, "%d bytes donwloaded... decompresssing... ", size)
+	fingerprint, err := VerifySignature(log, url, buf.Bytes(), policy)
+	if err != nil {
+		return err
+	}
+	step = log.Step(fmt.Sprintf("%d bytes donwloaded... decompresssing", size))
 	prefix := filepath.Join(CacheDirectory(), ver)
-	extractTar(prefix, readGzipFile(buf))
+	if strings.HasSuffix(url, ".zip") {
+		// Microsoft Windows binaries are packaged as zip files
+		err = extractZip(prefix, buf.Bytes())
+	} else {
+		var tarBuf *bytes.Buffer
+		if tarBuf, err = readGzipFile(buf); err == nil {
+			err = extractTar(prefix, tarBuf)
+		}
+	}
 	buf.Reset()
 	buf = nil
-	fmt.Fprintln(Output, utils.Ok("✔"))
+	if err != nil {
+		step.Fail(err)
+		return err
+	}
+	step.Ok()
 
-	return nil
+	// the fingerprint is kept when the installation metadata is recorded
+	return NewMetadata(ver, func(m *Metadata) { m.Signature = fingerprint }).Save()
 }
 
 // read the contents of a compressed gzip file
-func readGzipFile(data *bytes.Buffer) *bytes.Buffer {
+func readGzipFile(data *bytes.Buffer) (*bytes.Buffer, error) {
 	reader, err := gzip.NewReader(data)
 	if err != nil {
-		fmt.Fprintln(Output, "Fatal error reading gzip file contents...")
-		log.Fatal(err)
+		return nil, fmt.Errorf("error reading gzip file contents: %v", err)
 	}
 	defer reader.Close()
 	gzipBuf := new(bytes.Buffer)
 	if _, err := io.Copy(gzipBuf, reader); err != nil {
-		fmt.Fprintln(Output,
-			"Fatal error while reading gzip file contents into the buffer")
-		log.Fatal(err)
+		return nil, fmt.Errorf(
+			"error while reading gzip file contents into the buffer: %v", err)
 	}
 
-	return gzipBuf
+	return gzipBuf, nil
 }
 
 // extract the contents of the tar data into the given prefix
-func extractTar(prefix string, data *bytes.Buffer) {
+func extractTar(prefix string, data *bytes.Buffer) error {
 	tr := tar.NewReader(data)
 	if err := os.MkdirAll(filepath.Join(prefix, "go"), 0766); err != nil {
-		log.Fatal(err)
+		return err
 	}
 	for {
 		hdr, err := tr.Next()
 		if err != nil {
 			if err != io.EOF {
-				log.Fatal(err)
+				return err
 			}
 			break
 		}
@@ -134,20 +145,72 @@ func extractTar(prefix string, data *bytes.Buffer) {
 		if fi.IsDir() {
 			err := os.MkdirAll(filepath.Join(prefix, hdr.Name), 0766)
 			if err != nil && os.IsNotExist(err) {
-				log.Fatal(err)
+				return err
 			}
 		} else {
 			tw, err := os.OpenFile(
 				filepath.Join(prefix, hdr.Name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, fi.Mode())
 			if err != nil && !os.IsExist(err) {
-				log.Fatal(err)
-			}
-			if _, err := io.Copy(tw, tr); err != nil {
-				log.Fatal(err)
+				return err
 			}
+			_, err = io.Copy(tw, tr)
 			tw.Close()
+			if err != nil {
+				return err
+			}
 		}
 	}
 	data.Reset()
 	data = nil
+	return nil
+}
+
+// extract the contents of the zip data into the given prefix
+func extractZip(prefix string, data []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("error reading zip file contents: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(prefix, "go"), 0766); err != nil {
+		return err
+	}
+	for _, file := range zr.File {
+		target := filepath.Join(prefix, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(target, filepath.Clean(prefix)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file path in zip file: %s", file.Name)
+		}
+		fi := file.FileInfo()
+		if fi.IsDir() {
+			if err := os.MkdirAll(target, 0766); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0766); err != nil {
+			return err
+		}
+		if err := extractZipFile(target, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write the given zip file entry into target
+func extractZipFile(target string, file *zip.File) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	tw, err := os.OpenFile(
+		target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, file.FileInfo().Mode())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, rc)
+	if cerr := tw.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
@@ -22,7 +22,7 @@ package cache
 
 import (
 	"fmt"
-	"log"
+	"os"
 	"os/exec"
 	"path"
 	"runtime"
@@ -30,8 +30,11 @@ import (
 	"strings"
 )
 
-// Return the CacheDirctory for OS X, ~/Library/Caches/VenGO
+// Return the CacheDirctory for OS X, ~/Library/Caches/VenGO or $VENGO_CACHE
 func CacheDirectory() string {
+	if VENGO_CACHE := os.Getenv("VENGO_CACHE"); VENGO_CACHE != "" {
+		return VENGO_CACHE
+	}
 	return path.Join(ExpandUser("~"), "Library", "Caches", "VenGO")
 }
 
@@ -39,14 +42,17 @@ func CacheDirectory() string {
 func GetBinaryVersion(version string) string {
 	cmd := exec.Command("sw_vers", "-productVersion")
 	out, err := cmd.CombinedOutput()
+	major_ver := "10.6"
 	if err != nil {
-		log.Fatal(err)
+		// OS X 10.6 binaries run in any version
+		out = nil
 	}
-	major_ver := "10.6"
 	ver := strings.TrimRight(string(out), "\n")
-	numeric_ver, _ := strconv.ParseInt(ver[3:], 10, 64)
-	if numeric_ver >= int64(8) {
-		major_ver = "10.8"
+	if len(ver) > 3 {
+		numeric_ver, _ := strconv.ParseInt(strings.Split(ver[3:], ".")[0], 10, 64)
+		if numeric_ver >= int64(8) {
+			major_ver = "10.8"
+		}
 	}
 	return fmt.Sprintf("%s.darwin-%s-osx%s", version, runtime.GOARCH, major_ver)
 }
@@ -31,8 +31,11 @@ import (
 
 // Return the CacheDirectory for not darwin Unix. By default it is
 // ~/.cache/VenGO. On Linux, if the environemnt variable XDG_CACHE_HOME
-// exists it will be XDG_CACHE_HOME/VenGO
+// exists it will be XDG_CACHE_HOME/VenGO, VENGO_CACHE overrides both
 func CacheDirectory() string {
+	if VENGO_CACHE := os.Getenv("VENGO_CACHE"); VENGO_CACHE != "" {
+		return VENGO_CACHE
+	}
 	XDG_CACHE_HOME := os.Getenv("XDG_CACHE_HOME")
 	if XDG_CACHE_HOME == "" {
 		XDG_CACHE_HOME = ExpandUser("~/.cache")
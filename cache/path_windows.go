@@ -27,8 +27,11 @@ import (
 	"runtime"
 )
 
-// Return CacheDirectory on Windows, %APPDATA%\\VenGO
+// Return CacheDirectory on Windows, %APPDATA%\\VenGO or %VENGO_CACHE%
 func CacheDirectory() string {
+	if VENGO_CACHE := os.Getenv("VENGO_CACHE"); VENGO_CACHE != "" {
+		return VENGO_CACHE
+	}
 	APPDATA := os.Getenv("APPDATA")
 	if APPDATA == "" {
 		APPDATA = ExpandUser("~")
@@ -0,0 +1,159 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+// Move the cache into the given directory, the installation manifests and
+// the archive markers are rewritten to point to the new location, the
+// fingerprints of the directories in the manifests are updated too. The
+// VENGO_CACHE environment variable has to be set to the new location for
+// VenGO to use it
+func Relocate(log *logger.Logger, destination string) error {
+	source := CacheDirectory()
+	step := log.Step(fmt.Sprintf("Moving cache to %s", destination))
+	if err := MoveDirectory(source, destination); err != nil {
+		step.Fail(err)
+		return err
+	}
+	step.Ok()
+
+	replacer := NewPathReplacer(source, destination)
+	files, err := ioutil.ReadDir(destination)
+	if err != nil {
+		return err
+	}
+	step = log.Step("Rewriting installation manifests")
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		versionPath := filepath.Join(destination, file.Name())
+		err := rewriteManifest(filepath.Join(versionPath, ".vengo-manifest"), replacer)
+		if err == nil {
+			err = RewritePaths(filepath.Join(versionPath, archivedFile), replacer)
+		}
+		if err != nil {
+			step.Fail(err)
+			return err
+		}
+	}
+	step.Ok()
+	return nil
+}
+
+// move a directory, when it can't be renamed (for example because the
+// destination is in a different device) it is copied and then removed
+func MoveDirectory(source, destination string) error {
+	if _, err := os.Stat(destination); err == nil {
+		return fmt.Errorf("%s already exists", destination)
+	}
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(source, destination); err == nil {
+		return nil
+	}
+	out, err := exec.Command("cp", "-a", source, destination).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(destination)
+		return fmt.Errorf("%s", out)
+	}
+	return os.RemoveAll(source)
+}
+
+// PathReplacer replaces paths in a text, a path is replaced only when it is
+// not part of a longer path, /x/cache doesn't match /x/cache2 or /y/x/cache
+type PathReplacer struct {
+	pairs []string
+}
+
+// create a new path replacer from a list of old, new pairs of paths
+func NewPathReplacer(pairs ...string) *PathReplacer {
+	if len(pairs)%2 == 1 {
+		panic("NewPathReplacer: odd argument count")
+	}
+	return &PathReplacer{pairs: pairs}
+}
+
+// return back a copy of s with all the paths replaced
+func (r *PathReplacer) Replace(s string) string {
+	buffer := new(bytes.Buffer)
+	for i := 0; i < len(s); {
+		replaced := false
+		for n := 0; n < len(r.pairs); n += 2 {
+			old := r.pairs[n]
+			end := i + len(old)
+			if old == "" || !strings.HasPrefix(s[i:], old) ||
+				i > 0 && isPathRune(rune(s[i-1])) ||
+				end < len(s) && isPathRune(rune(s[end])) {
+				continue
+			}
+			buffer.WriteString(r.pairs[n+1])
+			i, replaced = end, true
+			break
+		}
+		if !replaced {
+			buffer.WriteByte(s[i])
+			i++
+		}
+	}
+	return buffer.String()
+}
+
+// checks if the given character can be part of a file name, the path
+// separators are not as they delimit the paths to replace
+func isPathRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune("._-+~@", r) || r >= utf8.RuneSelf
+}
+
+// rewrite the paths contained in the given file using the replacer, files
+// that doesn't exists are ignored
+func RewritePaths(file string, replacer *PathReplacer) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	rewritten := replacer.Replace(string(data))
+	if rewritten == string(data) {
+		return nil
+	}
+	return ioutil.WriteFile(file, []byte(rewritten), info.Mode())
+}
@@ -0,0 +1,204 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+// return the directory where custom Go repositories and forks are mirrored
+func ForksDirectory() string {
+	return filepath.Join(CacheDirectory(), "forks")
+}
+
+// Clone or fetch the given repository (URL or local path) and copy the
+// given reference (branch, tag or commit) into the cache as name
+func CacheDownloadRepository(log *logger.Logger, repo, ref, name string, f ...bool) (*Metadata, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("Git is not installed on this system.")
+	}
+	if name == "" {
+		return nil, errors.New("a name is required to install from a repository")
+	}
+	force := len(f) != 0 && f[0]
+	if Exists(name) && !force {
+		return nil, fmt.Errorf(
+			"%s already exists in the cache, use --force to reinstall it", name)
+	}
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+	logFile = openGitLogs(log)
+	repo = normalizeRepository(repo)
+	mirror, err := mirrorRepository(log, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	step := log.Step(fmt.Sprintf("Copying %s", describeReference(ref)))
+	destination := filepath.Join(CacheDirectory(), name)
+	os.RemoveAll(destination)
+	out, err := exec.Command("cp", "-R", mirror, destination).CombinedOutput()
+	if err != nil {
+		step.Fail(err)
+		return nil, fmt.Errorf("%s", out)
+	}
+	logOutput(log, out)
+	if err := checkoutReference(log, destination, ref); err != nil {
+		step.Fail(err)
+		os.RemoveAll(destination)
+		return nil, err
+	}
+	commit, err := gitOutput(log, destination, "rev-parse", "HEAD")
+	if err != nil {
+		step.Fail(err)
+		os.RemoveAll(destination)
+		return nil, err
+	}
+	step.Ok()
+
+	return NewMetadata(name, func(m *Metadata) {
+		m.Source = "repository"
+		m.Repository = repo
+		m.Reference = ref
+		m.Commit = commit
+	}), nil
+}
+
+// local paths are converted to absolute paths so they can be recorded
+func normalizeRepository(repo string) string {
+	if _, err := os.Stat(repo); err == nil {
+		if abs, err := filepath.Abs(repo); err == nil {
+			return abs
+		}
+	}
+	return repo
+}
+
+// clone the repository into the forks directory or fetch it if it is
+// already there, returns the path of the mirror
+func mirrorRepository(log *logger.Logger, repo string) (string, error) {
+	mirror := filepath.Join(ForksDirectory(), fmt.Sprintf("%x", sha1.Sum([]byte(repo)))[:12])
+	if _, err := os.Stat(mirror); err == nil {
+		step := log.Step(fmt.Sprintf("Fetching %s", repo))
+		cmd := exec.Command("git", "fetch", "--tags", "origin")
+		cmd.Dir = mirror
+		out, err := cmd.CombinedOutput()
+		logOutput(log, out)
+		if err != nil {
+			step.Fail(err)
+			return "", fmt.Errorf("%s", out)
+		}
+		step.Ok()
+		return mirror, nil
+	}
+
+	if err := os.MkdirAll(ForksDirectory(), 0755); err != nil {
+		return "", err
+	}
+	step := log.Step(fmt.Sprintf("Cloning %s", repo))
+	out, err := exec.Command("git", "clone", repo, mirror).CombinedOutput()
+	logOutput(log, out)
+	if err != nil {
+		step.Fail(err)
+		os.RemoveAll(mirror)
+		return "", fmt.Errorf("%s", out)
+	}
+	step.Ok()
+	return mirror, nil
+}
+
+// checkout the given reference in the repository at dir, references that
+// are not reachable from the cloned branches and tags are fetched first
+func checkoutReference(log *logger.Logger, dir, ref string) error {
+	if ref == "" {
+		return nil
+	}
+	if _, err := gitOutput(log, dir, "checkout", ref); err == nil {
+		return nil
+	}
+	if _, err := gitOutput(log, dir, "fetch", "origin", ref); err != nil {
+		return fmt.Errorf("%s can't be found in the repository: %v", ref, err)
+	}
+	_, err := gitOutput(log, dir, "checkout", "FETCH_HEAD")
+	return err
+}
+
+// run a git command in the given directory and return it's trimmed output
+func gitOutput(log *logger.Logger, dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	logOutput(log, out)
+	if err != nil {
+		return "", fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// human readable description of a reference
+func describeReference(ref string) string {
+	if ref == "" {
+		return "default branch"
+	}
+	return ref
+}
+
+// installation source for custom git repositories and forks
+type repositorySource struct{}
+
+func init() {
+	RegisterSource(repositorySource{})
+}
+
+func (repositorySource) Name() string { return "repository" }
+
+func (repositorySource) Describe(r *InstallRequest) string { return r.Repo }
+
+func (s repositorySource) Resolve(r *InstallRequest) error {
+	if r.Signature == SignatureRequired {
+		return unsignedError(s, r)
+	}
+	if r.Repo == "" {
+		return errors.New("a repository is required to install from a repository")
+	}
+	if r.Name == "" {
+		r.Name = r.Version
+	}
+	return nil
+}
+
+func (repositorySource) Fetch(r *InstallRequest) error {
+	metadata, err := CacheDownloadRepository(r.Log, r.Repo, r.Ref, r.Name, r.Force)
+	r.Metadata = metadata
+	return err
+}
+
+func (repositorySource) Build(r *InstallRequest) error {
+	return Compile(r.Log, r.Name, r.Verbose, r.NoCGO, r.BootStrap)
+}
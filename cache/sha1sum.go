@@ -38,20 +38,14 @@ func generateManifest(ver string) error {
 	if err := filepath.Walk(
 		versionPath,
 		func(path string, info os.FileInfo, err error) error {
-			if info.Name() == ".vengo-manifest" {
+			if info.Name() == ".vengo-manifest" || info.Name() == metadataFile {
 				return nil
 			}
-			data := []byte(path)
-			if !info.IsDir() {
-				var e error
-				data, e = ioutil.ReadFile(path)
-				if e != nil {
-					return fmt.Errorf("while generating manifest: %s", e)
-				}
+			sum, e := fileSha1(path, info)
+			if e != nil {
+				return fmt.Errorf("while generating manifest: %s", e)
 			}
-
-			fileSha1 := fmt.Sprintf("%x %s", sha1.Sum(data), path)
-			manifest = append(manifest, fileSha1)
+			manifest = append(manifest, fmt.Sprintf("%s %s", sum, path))
 			return nil
 		},
 	); err != nil {
@@ -80,18 +74,14 @@ func CheckManifestIntegrity(manifestName string) error {
 	for {
 		line, err := reader.ReadString('\n')
 		if line != "" {
-			splitData := strings.Split(line, " ")
+			splitData := strings.SplitN(line, " ", 2)
 			f := strings.TrimRight(splitData[1], "\n\r")
-			fi, statErr := os.Stat(f)
+			fi, statErr := os.Lstat(f)
 			if statErr != nil {
 				return fmt.Errorf("Integrity check failed! %s", statErr)
 			}
-			data := []byte(f)
-			if !fi.IsDir() {
-				data, _ = ioutil.ReadFile(f)
-			}
-			fileSha1 := fmt.Sprintf("%x", sha1.Sum(data))
-			if splitData[0] != fileSha1 {
+			sum, _ := fileSha1(f, fi)
+			if splitData[0] != sum {
 				return fmt.Errorf("Integrity check failed!")
 			}
 		}
@@ -105,3 +95,59 @@ func CheckManifestIntegrity(manifestName string) error {
 	}
 	return nil
 }
+
+// rewrite the paths of a manifest using the replacer, the directories are
+// identified by their path so their fingerprint is updated too. Entries that
+// don't match the fingerprint of their previous path are left untouched so
+// they still fail the integrity check. Manifests that doesn't exists are
+// ignored
+func rewriteManifest(manifestName string, replacer *PathReplacer) error {
+	info, err := os.Stat(manifestName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := ioutil.ReadFile(manifestName)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for n, line := range lines {
+		splitData := strings.SplitN(line, " ", 2)
+		if len(splitData) != 2 {
+			continue
+		}
+		sum, path := splitData[0], strings.TrimRight(splitData[1], "\r")
+		relocated := replacer.Replace(path)
+		if relocated == path {
+			continue
+		}
+		if sum == fmt.Sprintf("%x", sha1.Sum([]byte(path))) {
+			sum = fmt.Sprintf("%x", sha1.Sum([]byte(relocated)))
+		}
+		lines[n] = fmt.Sprintf("%s %s", sum, relocated)
+	}
+	return ioutil.WriteFile(
+		manifestName, []byte(strings.Join(lines, "\n")+"\n"), info.Mode())
+}
+
+// return the SHA1 fingerprint of a manifest entry, directories are identified
+// by their path and symbolic links by their target
+func fileSha1(path string, info os.FileInfo) (string, error) {
+	data := []byte(path)
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		data = []byte(target)
+	} else if !info.IsDir() {
+		var err error
+		if data, err = ioutil.ReadFile(path); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", sha1.Sum(data)), nil
+}
@@ -0,0 +1,129 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+// signature verification policy of the downloads
+type SignaturePolicy int
+
+// signature verification policies
+const (
+	SignatureIfAvailable SignaturePolicy = iota
+	SignatureRequired
+)
+
+// return back the OpenPGP keyring used to verify the downloads, it can be
+// configured using the VENGO_KEYRING environment variable or placing one in
+// the VenGO home, the keyring bundled by the installer is used otherwise
+func Keyring() string {
+	if keyring := os.Getenv("VENGO_KEYRING"); keyring != "" {
+		return keyring
+	}
+	keyring := filepath.Join(VenGO_PATH, "keyring.gpg")
+	if _, err := os.Stat(keyring); err == nil {
+		return keyring
+	}
+	return BundledKeyring()
+}
+
+// return back the keyring with the Go releases signing key that the
+// installer bundles into the VenGO home
+func BundledKeyring() string {
+	return filepath.Join(VenGO_PATH, "share", "keyring.gpg")
+}
+
+// Verify the detached OpenPGP signature (url.asc) of the given downloaded
+// data against the VenGO keyring, returns back the fingerprint of the key
+// that made the signature. If the policy is not SignatureRequired downloads
+// with no keyring, gpg or signature available are not verified
+func VerifySignature(log *logger.Logger, url string, data []byte, policy SignaturePolicy) (string, error) {
+	skip := func(reason string) (string, error) {
+		if policy == SignatureRequired {
+			return "", fmt.Errorf("signature is required but %s", reason)
+		}
+		return "", nil
+	}
+	keyring := Keyring()
+	if _, err := os.Stat(keyring); err != nil {
+		return skip(fmt.Sprintf("the keyring %s doesn't exists", keyring))
+	}
+	if _, err := exec.LookPath("gpg"); err != nil {
+		return skip("gpg is not installed on this system")
+	}
+	signature, err := httpDownload(url + ".asc")
+	if err != nil {
+		return skip(fmt.Sprintf("the signature can't be downloaded: %v", err))
+	}
+
+	step := log.Step("Verifying signature")
+	fingerprint, err := gpgVerify(keyring, signature, data)
+	if err != nil {
+		step.Fail(err)
+		return "", err
+	}
+	step.Ok()
+	return fingerprint, nil
+}
+
+// run gpg to verify the signature of the data using only the given keyring
+func gpgVerify(keyring string, signature, data []byte) (string, error) {
+	dir, err := ioutil.TempDir("", "vengo-signature")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	signatureFile := filepath.Join(dir, "download.asc")
+	dataFile := filepath.Join(dir, "download")
+	if err := ioutil.WriteFile(signatureFile, signature, 0600); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(dataFile, data, 0600); err != nil {
+		return "", err
+	}
+	// the temporary directory is used as gpg home to not depend on the
+	// user trust database
+	out, _ := exec.Command(
+		"gpg", "--batch", "--homedir", dir,
+		"--no-default-keyring", "--keyring", keyring,
+		"--status-fd", "1", "--verify", signatureFile, dataFile,
+	).Output()
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 2 && fields[0] == "[GNUPG:]" && fields[1] == "VALIDSIG" {
+			return fields[2], nil
+		}
+	}
+	return "", errors.New("the download signature is not valid for the VenGO keyring")
+}
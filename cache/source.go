@@ -24,37 +24,37 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 
-	"github.com/DamnWidget/VenGO/utils"
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/mcuadros/go-version"
 )
 
 // determine if a Go version has been already compiled in the cache
-func AlreadyCompiled(ver string) bool {
-	manifest := filepath.Join(CacheDirectory(), ver, ".vengo-manifest")
+func AlreadyCompiled(log *logger.Logger, ver string) bool {
+	manifest := filepath.Join(VersionPath(ver), ".vengo-manifest")
 	if _, err := os.Stat(manifest); err != nil {
 		return false
 	}
-	fmt.Fprint(Output, "Checking manifest integrity... ")
+	step := log.Step("Checking manifest integrity", "version", ver)
 	if err := CheckManifestIntegrity(manifest); err != nil {
-		fmt.Println(utils.Fail("✖"))
-		log.Println(err)
+		step.Fail(err)
+		log.Debug(err.Error())
 		return false
 	}
-	fmt.Fprintln(Output, utils.Ok("✔"))
+	step.Ok()
 	return true
 }
 
 // compile a given version of go in the cache
-func Compile(ver string, verbose, nocgo bool, boostrap ...string) error {
-	fmt.Fprint(Output, "Compiling... ")
+func Compile(log *logger.Logger, ver string, verbose, nocgo bool, boostrap ...string) error {
+	step := log.Step("Compiling", "version", ver)
 	if verbose {
-		fmt.Fprint(Output, "\n")
+		log.Info("")
 	}
 
 	bs := ""
@@ -62,35 +62,44 @@ func Compile(ver string, verbose, nocgo bool, boostrap ...string) error {
 		bs = boostrap[0]
 	}
 
-	currdir, _ := os.Getwd()
 	prefixed := false
-	err := os.Chdir(filepath.Join(CacheDirectory(), ver, "go", "src"))
-	if err != nil {
-		if !strings.HasPrefix(ver, "go") && ver != "tip" {
+	srcDir := filepath.Join(CacheDirectory(), ver, "go", "src")
+	if _, err := os.Stat(srcDir); err != nil {
+		// custom named versions (e.g. forks) are never prefixed
+		if !strings.HasPrefix(ver, "go") && ver != "tip" && !Exists(ver) {
 			ver = fmt.Sprintf("go%s", ver)
 		}
 		prefixed = true
-		if err := os.Chdir(
-			filepath.Join(CacheDirectory(), ver, "src")); err != nil {
-			if !verbose {
-				fmt.Fprintln(Output, utils.Fail("✖"))
-			}
+		srcDir = filepath.Join(CacheDirectory(), ver, "src")
+		if _, err := os.Stat(srcDir); err != nil {
+			step.Fail(err)
 			return err
 		}
 	}
-	defer func() { os.Chdir(currdir) }()
 
 	cmd := "./make.bash"
 	if runtime.GOOS == "windows" {
 		cmd = "./make.bat"
 	}
+	// the environment is passed to the command instead of modify the
+	// process one so several versions can be compiled concurrently
+	env := []string{}
 	if nocgo {
-		os.Setenv("CGO_ENABLED", "0")
+		env = append(env, "CGO_ENABLED=0")
 	}
 	if bs != "" {
-		os.Setenv("GOROOT_BOOTSTRAP", bs)
+		env = append(env, "GOROOT_BOOTSTRAP="+bs)
+	}
+	// the compilation output is shown through the logger when verbose
+	make := exec.Command(cmd)
+	make.Dir = srcDir
+	make.Env = append(os.Environ(), env...)
+	if verbose {
+		make.Stdout = log.LineWriter(logger.Info)
+		make.Stderr = log.LineWriter(logger.Warn)
 	}
-	if err := utils.Exec(verbose, cmd); err != nil {
+	if err := make.Run(); err != nil {
+		step.Fail(err)
 		return err
 	}
 	goBin := filepath.Join(CacheDirectory(), ver, "go", "bin", "go")
@@ -98,38 +107,33 @@ func Compile(ver string, verbose, nocgo bool, boostrap ...string) error {
 		goBin = filepath.Join(CacheDirectory(), ver, "bin", "go")
 	}
 	if _, err := os.Stat(goBin); err != nil {
-		if !verbose {
-			fmt.Fprintln(Output, utils.Fail("✖"))
-		}
-		fmt.Fprintln(Output, err)
+		step.Fail(err)
 		return fmt.Errorf("Go %s wasn't compiled properly! %v", ver, err)
 	}
-	if !verbose {
-		fmt.Fprintln(Output, utils.Ok("✔"))
-	}
-	fmt.Fprintf(Output, "Generating manifest... ")
+	step.Ok()
+	step = log.Step("Generating manifest", "version", ver)
 	if err := generateManifest(ver); err != nil {
 		os.RemoveAll(filepath.Join(CacheDirectory(), ver))
-		fmt.Fprintln(Output, utils.Fail("✖"))
+		step.Fail(err)
 		return err
 	}
-	fmt.Fprintln(Output, utils.Ok("✔"))
+	step.Ok()
 
 	return nil
 }
 
 // log compilation process
-func logCompilation(rd, erd *bufio.Reader) {
+func logCompilation(log *logger.Logger, rd, erd *bufio.Reader) {
 	go func() {
 		for {
 			str, err := rd.ReadString('\n')
 			if err != nil {
 				if err != io.EOF {
-					log.Fatal(err)
+					log.Error(err.Error())
 				}
 				break
 			}
-			fmt.Printf("%s", str)
+			log.Info(strings.TrimRight(str, "\n"))
 		}
 	}()
 
@@ -140,18 +144,24 @@ func logCompilation(rd, erd *bufio.Reader) {
 			if err != nil {
 				break
 			}
-			fmt.Printf("%s", str)
+			log.Warn(strings.TrimRight(str, "\n"))
 		}
 	}()
 }
 
 // Download an specific version of Golang source code
-func CacheDownload(ver string, f ...bool) error {
+func CacheDownload(log *logger.Logger, ver string, f ...bool) error {
+	force := (len(f) != 0 && f[0] == true)
+	return cacheDownload(log, ver, force, SignatureIfAvailable)
+}
+
+// download the source code of the given version verifying its signature
+// with the given policy
+func cacheDownload(log *logger.Logger, ver string, force bool, policy SignaturePolicy) error {
 	expected_sha1, err := Checksum(ver)
 	if err != nil {
 		return err
 	}
-	force := (len(f) != 0 && f[0] == true)
 
 	if !Exists(ver) || force {
 		url := fmt.Sprintf(
@@ -160,10 +170,34 @@ func CacheDownload(ver string, f ...bool) error {
 			url = fmt.Sprintf(
 				"https://go.googlecode.com/files/go%s.src.tar.gz", ver)
 		}
-		if err := downloadAndExtract(ver, url, expected_sha1); err != nil {
+		if err := downloadAndExtract(log, ver, url, expected_sha1, policy); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// installation source for the tar.gz packaged sources
+type tarballSource struct{}
+
+func init() {
+	RegisterSource(tarballSource{})
+}
+
+func (tarballSource) Name() string { return "tarball" }
+
+func (tarballSource) Describe(r *InstallRequest) string { return "tar.gz source" }
+
+func (tarballSource) Resolve(r *InstallRequest) error {
+	r.Name = r.Version
+	return nil
+}
+
+func (tarballSource) Fetch(r *InstallRequest) error {
+	return cacheDownload(r.Log, r.Version, r.Force, r.Signature)
+}
+
+func (tarballSource) Build(r *InstallRequest) error {
+	return Compile(r.Log, r.Version, r.Verbose, r.NoCGO, r.BootStrap)
+}
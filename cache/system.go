@@ -0,0 +1,73 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// default location of the read-only cache shared by all the users
+const systemCache = "/opt/vengo/cache"
+
+// error returned when an operation would modify a Go version that is
+// provided by the system cache
+var ErrSystemCache = errors.New("the system cache can't be modified")
+
+// return back an ErrSystemCache error for the given Go version
+func systemCacheError(ver string) error {
+	return fmt.Errorf("%s is installed in the system cache %s: %w",
+		ver, SystemCacheDirectory(), ErrSystemCache)
+}
+
+// return back the system wide cache directory, it is populated by an admin
+// and VenGO never writes into it. The VENGO_SYSTEM_CACHE environment
+// variable overrides the default location
+func SystemCacheDirectory() string {
+	if VENGO_SYSTEM_CACHE := os.Getenv("VENGO_SYSTEM_CACHE"); VENGO_SYSTEM_CACHE != "" {
+		return VENGO_SYSTEM_CACHE
+	}
+	return systemCache
+}
+
+// return back the path of the given Go version, versions in the user cache
+// take precedence over the system ones, the user cache path is returned for
+// versions that are not installed in any of them
+func VersionPath(ver string) string {
+	if InSystemCache(ver) {
+		return filepath.Join(SystemCacheDirectory(), ver)
+	}
+	return filepath.Join(CacheDirectory(), ver)
+}
+
+// checks if the given Go version is provided by the system cache
+func InSystemCache(ver string) bool {
+	if Exists(ver) {
+		return false
+	}
+	if filepath.Clean(SystemCacheDirectory()) == filepath.Clean(CacheDirectory()) {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(SystemCacheDirectory(), ver))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/DamnWidget/VenGO/cache"
+	"github.com/DamnWidget/VenGO/logger"
+	"github.com/DamnWidget/VenGO/utils"
+)
+
+var cmdAdopt = &Command{
+	Name:  "adopt",
+	Usage: "adopt [-c] [-f] [-n name] goroot",
+	Short: "Adopt an existing Go installation into the cache",
+	Long: `Registers an already existing Go installation (for example one installed by
+your distribution or by hand into /usr/local/go) into the VenGO cache so it can
+be used to create environments as any other installed Go version:
+
+    vengo adopt /usr/local/go --name system-1.4
+
+By default the installation is registered by reference, VenGO links the GOROOT
+contents into the cache and generates a manifest for it. If the -c or --copy
+flag is passed, the whole GOROOT is copied into the cache instead.
+
+If no name is given with the -n or --name flag, the installation is registered
+as system-<version> where version is the detected Go version. An already
+registered name can be replaced using the -f or --force flag.
+`,
+	Execute: runAdopt,
+}
+
+var (
+	nameAdopt  string
+	copyAdopt  bool
+	forceAdopt bool
+)
+
+// initialize the command
+func init() {
+	cmdAdopt.Flag.StringVarP(&nameAdopt, "name", "n", "", "cache name")
+	cmdAdopt.Flag.BoolVarP(&copyAdopt, "copy", "c", false, "copy GOROOT")
+	cmdAdopt.Flag.BoolVarP(&forceAdopt, "force", "f", false, "force")
+	cmdAdopt.register()
+}
+
+// run the adopt command
+func runAdopt(cmd *Command, args ...string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	options := func(a *Adopt) {
+		a.Goroot = args[0]
+		a.Name = nameAdopt
+		a.Copy = copyAdopt
+		a.Force = forceAdopt
+		a.Log = cmd.Log
+	}
+	a := NewAdopt(options)
+	data, err := a.Run()
+	if err != nil {
+		return err
+	}
+	fmt.Println(data)
+	return nil
+}
+
+// adopt command
+type Adopt struct {
+	Goroot string
+	Name   string
+	Copy   bool
+	Force  bool
+	Log    *logger.Logger
+}
+
+// create a new adopt command and return back it's address
+func NewAdopt(options ...func(a *Adopt)) *Adopt {
+	adopt := new(Adopt)
+	for _, option := range options {
+		option(adopt)
+	}
+	return adopt
+}
+
+// implements the Runner interface registering the GOROOT into the cache
+func (a *Adopt) Run() (string, error) {
+	metadata, err := cache.Adopt(a.Log, a.Goroot, a.Name, a.Copy, a.Force)
+	if err != nil {
+		return "", err
+	}
+	return utils.Ok(fmt.Sprintf(
+		"%s (%s) adopted as %s", metadata.Origin, metadata.GoVersion, metadata.Name,
+	)), nil
+}
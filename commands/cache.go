@@ -0,0 +1,308 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DamnWidget/VenGO/cache"
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
+	"github.com/DamnWidget/VenGO/utils"
+)
+
+var cmdCache = &Command{
+	Name:  "cache",
+	Usage: "cache dedupe [version...] | archive [--older-than age] [version...] | restore [-e env] [version...]",
+	Short: "Manage the Go versions cache",
+	Long: `Performs maintenance operations over the VenGO cache where the installed Go
+versions live. The available operations are:
+
+  dedupe    Replace files that are identical across the installed Go versions
+            with hardlinks to a content addressed store inside the cache. If
+            no versions are given, every installed version is deduplicated.
+            The integrity manifests of the versions remain valid.
+
+  archive   Compress the given versions into an archive inside the cache
+            leaving a small stub in their place. With --older-than, every
+            version that has not been used by any environment in the given
+            age (e.g. 90d, 12h) is archived.
+
+  restore   Extract the given archived versions back into the cache, with
+            -e or --env the version used by the given environment is
+            restored. Archived versions are restored transparently when
+            they are needed by mkenv, import, migrate or vengo_activate.
+
+Deduplication can also be done at installation time passing the --dedupe flag
+to the 'vengo install' command.
+`,
+	Execute: runCache,
+}
+
+var (
+	olderThanCache string
+	envCache       string
+)
+
+// initialize the command
+func init() {
+	cmdCache.Flag.StringVarP(&olderThanCache, "older-than", "o", "", "")
+	cmdCache.Flag.StringVarP(&envCache, "env", "e", "", "")
+	cmdCache.register()
+}
+
+// run the cache command
+func runCache(cmd *Command, args ...string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	var runner Runner
+	switch args[0] {
+	case "dedupe":
+		runner = NewDedupe(func(d *Dedupe) {
+			d.Versions = args[1:]
+			d.Log = cmd.Log
+		})
+	case "archive":
+		age, err := parseAge(olderThanCache)
+		if err != nil {
+			return err
+		}
+		if age == 0 && len(args) == 1 {
+			return ErrUsage
+		}
+		runner = NewArchive(func(a *Archive) {
+			a.Versions = args[1:]
+			a.OlderThan = age
+			a.Log = cmd.Log
+		})
+	case "restore":
+		if envCache == "" && len(args) == 1 {
+			return ErrUsage
+		}
+		runner = NewRestore(func(r *Restore) {
+			r.Versions = args[1:]
+			r.Environment = envCache
+			r.Log = cmd.Log
+		})
+	default:
+		return ErrUsage
+	}
+	data, err := runner.Run()
+	if err != nil {
+		return err
+	}
+	fmt.Println(data)
+	return nil
+}
+
+// cache dedupe command
+type Dedupe struct {
+	Versions []string
+	Log      *logger.Logger
+}
+
+// create a new dedupe command and return back it's address
+func NewDedupe(options ...func(d *Dedupe)) *Dedupe {
+	dedupe := new(Dedupe)
+	for _, option := range options {
+		option(dedupe)
+	}
+	return dedupe
+}
+
+// implements the Runner interface deduplicating the cache files
+func (d *Dedupe) Run() (string, error) {
+	saved, err := cache.Dedupe(d.Log, d.Versions...)
+	if err != nil {
+		return "", err
+	}
+	return utils.Ok(fmt.Sprintf("%s saved", humanBytes(saved))), nil
+}
+
+// cache archive command
+type Archive struct {
+	Versions  []string
+	OlderThan time.Duration
+	Log       *logger.Logger
+}
+
+// create a new archive command and return back it's address
+func NewArchive(options ...func(a *Archive)) *Archive {
+	archive := new(Archive)
+	for _, option := range options {
+		option(archive)
+	}
+	return archive
+}
+
+// implements the Runner interface archiving the given or unused versions
+func (a *Archive) Run() (string, error) {
+	versions := a.Versions
+	if a.OlderThan > 0 {
+		unused, err := unusedVersions(time.Now().Add(-a.OlderThan))
+		if err != nil {
+			return "", err
+		}
+		versions = append(versions, unused...)
+	}
+	if len(versions) == 0 {
+		return utils.Ok("nothing to archive"), nil
+	}
+	var saved int64
+	for _, ver := range versions {
+		s, err := cache.Archive(a.Log, ver)
+		if err != nil {
+			return "", err
+		}
+		saved += s
+	}
+	return utils.Ok(fmt.Sprintf(
+		"%d versions archived, %s saved", len(versions), humanBytes(saved))), nil
+}
+
+// load the given environment to use it, the Go version linked into the
+// environment is restored first if it has been archived
+func loadRestoredEnvironment(name string, log *logger.Logger) (*env.Environment, error) {
+	environment, err := env.LoadEnvironment(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a VenGO environment: %v", name, err)
+	}
+	environment.Log = log
+	restore := NewRestore(func(r *Restore) {
+		r.Environment = name
+		r.Log = log
+	})
+	if _, err := restore.Run(); err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
+
+// cache restore command
+type Restore struct {
+	Versions    []string
+	Environment string
+	Log         *logger.Logger
+}
+
+// create a new restore command and return back it's address
+func NewRestore(options ...func(r *Restore)) *Restore {
+	restore := new(Restore)
+	for _, option := range options {
+		option(restore)
+	}
+	return restore
+}
+
+// implements the Runner interface restoring archived versions
+func (r *Restore) Run() (string, error) {
+	versions := r.Versions
+	// the environment version is only restored if it's archived, so the
+	// environments with a broken Go version can still be activated
+	if r.Environment != "" {
+		ver, err := env.LinkedVersion(r.Environment)
+		if err == nil && cache.IsArchived(ver) {
+			versions = append(versions, ver)
+		}
+	}
+	for _, ver := range versions {
+		if _, err := os.Stat(cache.VersionPath(ver)); err != nil {
+			return "", fmt.Errorf("%s is not a Go installed version", ver)
+		}
+		if err := cache.Restore(r.Log, ver); err != nil {
+			return "", err
+		}
+	}
+	return utils.Ok("Done"), nil
+}
+
+// return the cached versions whose environments have not been activated
+// since the given time, versions that are not used by any environment are
+// considered unused since they were installed
+func unusedVersions(since time.Time) ([]string, error) {
+	installed, err := cache.ManifestedVersions()
+	if err != nil {
+		return nil, err
+	}
+	environments, err := env.Environments()
+	if err != nil {
+		return nil, err
+	}
+	lastUsed := map[string]time.Time{}
+	for _, ver := range installed {
+		info, err := os.Stat(
+			filepath.Join(cache.CacheDirectory(), ver, ".vengo-manifest"))
+		if err == nil {
+			lastUsed[ver] = info.ModTime()
+		}
+	}
+	for _, name := range environments {
+		ver, err := env.LinkedVersion(name)
+		if err != nil {
+			continue
+		}
+		if activated := env.LastActivated(name); activated.After(lastUsed[ver]) {
+			lastUsed[ver] = activated
+		}
+	}
+	unused := []string{}
+	for _, ver := range installed {
+		if lastUsed[ver].Before(since) {
+			unused = append(unused, ver)
+		}
+	}
+	return unused, nil
+}
+
+// parse an age like 90d, 36h or 30m, days are not supported by time
+func parseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %s", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(age)
+}
+
+// format a number of bytes in a human readable way
+func humanBytes(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	size, unit := float64(n), 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", n, units[unit])
+	}
+	return fmt.Sprintf("%.2f %s", size, units[unit])
+}
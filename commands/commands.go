@@ -23,10 +23,10 @@ package commands
 import (
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"text/template"
 
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/DamnWidget/VenGO/utils"
 	flag "github.com/ogier/pflag"
 
@@ -43,7 +43,7 @@ const (
 const (
 	// command template
 	commandTpl = `
-Usage: vengo command [arguments]
+Usage: vengo [--quiet] [--verbose] [--log-format text|json] command [arguments]
 
 Where command can be one of the list below:
 {{ range . }}
@@ -61,17 +61,18 @@ Usage: vengo {{ .Usage }}
 
 var suggest = utils.Ok("suggestion")
 
-// execute command function type
-type commandFunc func(cmd *Command, args ...string)
+// execute command function type, the returned error is reported by main
+type commandFunc func(cmd *Command, args ...string) error
 
 // command structure
 type Command struct {
-	Name    string       // command name
-	Usage   string       // short line that contains the usage help
-	Short   string       // short description
-	Long    string       // long description
-	Execute commandFunc  // run the command
-	Flag    flag.FlagSet // set of flags for this command
+	Name    string         // command name
+	Usage   string         // short line that contains the usage help
+	Short   string         // short description
+	Long    string         // long description
+	Execute commandFunc    // run the command
+	Flag    flag.FlagSet   // set of flags for this command
+	Log     *logger.Logger // logger passed to the command runners
 }
 
 // return a string representation of the command
@@ -79,11 +80,28 @@ func (cmd *Command) String() string {
 	return cmd.Name
 }
 
-// display the usage and exit
-func (cmd *Command) DisplayUsageAndExit() {
+// display the usage
+func (cmd *Command) DisplayUsage() {
 	fmt.Printf("Usage: vengo %s\n\n", cmd.Usage)
 	fmt.Printf("%s: execute 'vengo' with no arguments to get a list of valid commands\n", suggest)
-	os.Exit(2)
+}
+
+// display an error returned by the command, the usage is displayed when
+// the command has been called with wrong arguments
+func (cmd *Command) DisplayError(err error) {
+	if errors.Is(err, ErrUsage) {
+		cmd.DisplayUsage()
+		return
+	}
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		return
+	}
+	fmt.Println(utils.Fail(fmt.Sprintf("error: %v", err)))
+	var e *Error
+	if errors.As(err, &e) && e.Suggestion != "" {
+		fmt.Printf("%s: %s\n", suggest, e.Suggestion)
+	}
 }
 
 // register the command in the commands list
@@ -103,38 +121,62 @@ func Version(version string) {
 	fmt.Printf("VenGO, Virtual Golang Environment builder %s\n", version)
 }
 
-// help function
-func Help(args ...string) {
+// help function, prints the usage information if no command is given and
+// returns ErrUsage if the command doesn't exists
+func Help(args ...string) error {
 	if len(args) == 0 {
-		usage()
-		return
+		return usage()
 	}
 	cmd, ok := Commands[args[0]]
 	if !ok {
 		NonCommand(args[0])
-		os.Exit(2)
+		return ErrUsage
 	}
 	t := template.New("help")
 	template.Must(t.Parse(fmt.Sprintf("%s\n\n", strings.TrimSpace(helpTpl))))
-	if err := t.Execute(os.Stdout, cmd); err != nil {
-		log.Fatal(err)
-	}
+	return t.Execute(os.Stdout, cmd)
 }
 
-// calls usage to prints usage information and exits
-func Usage() {
-	usage()
-	os.Exit(2)
+// calls usage to prints usage information and returns ErrUsage so main
+// exits with the usage exit code
+func Usage() error {
+	if err := usage(); err != nil {
+		return err
+	}
+	return ErrUsage
 }
 
 // print usage information
-func usage() {
+func usage() error {
 	t := template.New("usage")
 	t.Funcs(template.FuncMap{"Ok": utils.Ok})
 	template.Must(t.Parse(fmt.Sprintf("%s\n\n", strings.TrimSpace(commandTpl))))
-	if err := t.Execute(os.Stdout, Commands); err != nil {
-		log.Fatal(err)
+	return t.Execute(os.Stdout, Commands)
+}
+
+// create the logger passed to the commands using the global command line
+// flags, quiet only shows errors and verbose shows debug events like the
+// output of the executed git commands
+func NewLogger(quiet, verbose bool, format string) (*logger.Logger, error) {
+	log := logger.New()
+	switch format {
+	case "", "text":
+		log.Format = logger.Text
+	case "json":
+		log.Format = logger.Json
+	default:
+		return nil, fmt.Errorf("unknown log format %s, use text or json", format)
+	}
+	if quiet && verbose {
+		return nil, errors.New("--quiet and --verbose can't be used together")
+	}
+	if quiet {
+		log.Level = logger.Error
 	}
+	if verbose {
+		log.Level = logger.Debug
+	}
+	return log, nil
 }
 
 // Runner is a interface that wraps the execution of a command
@@ -145,6 +187,43 @@ type Runner interface {
 	Run() (string, error)
 }
 
+// error returned when a command is called with wrong arguments
+var ErrUsage = errors.New("wrong command arguments")
+
+// Error is returned by the commands when the error comes with a suggestion
+// for the user about how to solve it
+type Error struct {
+	Err        error
+	Suggestion string
+}
+
+// create a new command error with the given suggestion
+func suggestError(err error, format string, args ...interface{}) *Error {
+	return &Error{Err: err, Suggestion: fmt.Sprintf(format, args...)}
+}
+
+// return back the message of the wrapped error
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// return back the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitError is returned by the commands that run other programs when they
+// fail, the program already reported the problem so main exits with the
+// same exit code without displaying anything
+type ExitError struct {
+	Code int
+}
+
+// return back the error message
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
 // error used when Go version used for mkenv is not installed yet
 var ErrNotInstalled = errors.New("Go version not installed")
 
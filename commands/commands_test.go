@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"github.com/DamnWidget/VenGO/cache"
 	"github.com/DamnWidget/VenGO/commands"
 	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/DamnWidget/VenGO/utils"
 )
 
@@ -24,14 +26,35 @@ func runningOnTravis() bool {
 	return strings.Contains(c, "travis")
 }
 
+// create a VenGO home and cache in a temporary directory with an environment
+// linked to an empty go1.4 version, the options configure the environment
+// before its scripts are generated. It returns back the temporary directory
+// and a function that removes it and restores the previous locations
+func environmentSandbox(name string, options ...func(e *env.Environment)) (string, func()) {
+	vengoPath := cache.VenGO_PATH
+	root, err := ioutil.TempDir("", "VenGOCommandsTest")
+	Expect(err).ToNot(HaveOccurred())
+	os.Setenv("VENGO_CACHE", filepath.Join(root, "cache"))
+	cache.VenGO_PATH = filepath.Join(root, "home")
+	Expect(os.MkdirAll(filepath.Join(cache.CacheDirectory(), "go1.4"), 0755)).To(Succeed())
+	e := env.NewEnvironment(name, "("+name+")")
+	for _, option := range options {
+		option(e)
+	}
+	Expect(e.Generate()).To(Succeed())
+	Expect(env.Relink(name, "go1.4")).To(Succeed())
+	return root, func() {
+		os.RemoveAll(root)
+		os.Setenv("VENGO_CACHE", "")
+		cache.VenGO_PATH = vengoPath
+	}
+}
+
 var _ = Describe("Commands", func() {
 	if runningOnTravis() {
 		return
 	}
 
-	// disable log output
-	cache.Output = ioutil.Discard
-
 	Describe("NewList", func() {
 		It("Create and return a configured list", func() {
 			showBoth := func(l *commands.List) {
@@ -476,8 +499,479 @@ var _ = Describe("Commands", func() {
 				out, err := i.Run()
 				Expect(err).To(HaveOccurred())
 				Expect(out).To(Equal("error while installing from github"))
-				Expect(err).To(Equal(fmt.Errorf("go20.1 doesn't seems to be a valid Go release\n")))
+				Expect(err).To(MatchError("go20.1 doesn't seems to be a valid Go release\n"))
+				Expect(cache.IsVersionUnknown(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("InstallMany", func() {
+		It("Should report the result of every installation", func() {
+			invalid := func(i *commands.Install) {
+				i.Source = 42
+			}
+			m := commands.NewInstallMany(func(m *commands.InstallMany) {
+				m.Jobs = 2
+				m.Installs = []*commands.Install{
+					commands.NewInstall(invalid, func(i *commands.Install) { i.Version = "1.3.3" }),
+					commands.NewInstall(invalid, func(i *commands.Install) { i.Version = "1.4.2" }),
+				}
+			})
+
+			out, err := m.Run()
+			Expect(err).To(Equal(fmt.Errorf("2 of 2 installations failed")))
+			Expect(out).To(ContainSubstring("1.3.3"))
+			Expect(out).To(ContainSubstring("1.4.2"))
+			Expect(out).To(ContainSubstring("Install.Source is not a valid source"))
+		})
+	})
+
+	Describe("Upgrade", func() {
+		var vengoPath = cache.VenGO_PATH
+
+		BeforeEach(func() {
+			cache.VenGO_PATH = filepath.Join(os.TempDir(), "VenGOUpgradeTest")
+			e := env.NewEnvironment("upgradeTest", "(upgradeTest)")
+			Expect(e.Generate()).To(Succeed())
+			Expect(env.Relink("upgradeTest", "1.3")).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(cache.VenGO_PATH)
+			cache.VenGO_PATH = vengoPath
+		})
+
+		It("Should plan the upgrade to the newest patch release", func() {
+			u := commands.NewUpgrade(func(u *commands.Upgrade) {
+				u.DryRun = true
+			})
+
+			out, err := u.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(MatchRegexp(`upgradeTest\s+1.3\s+1.3.3\s+will be upgraded`))
+		})
+
+		It("Should plan the upgrade to the newest minor release with Minor", func() {
+			u := commands.NewUpgrade(func(u *commands.Upgrade) {
+				u.DryRun = true
+				u.Minor = true
+				u.Environments = []string{"upgradeTest"}
+			})
+
+			out, err := u.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(MatchRegexp(`upgradeTest\s+1.3\s+1.3.3\s+`))
+			Expect(out).To(ContainSubstring("will be upgraded"))
+		})
+	})
+
+	Describe("Relocate", func() {
+		var vengoPath = cache.VenGO_PATH
+		var root string
+
+		BeforeEach(func() {
+			var err error
+			root, err = ioutil.TempDir("", "VenGORelocateTest")
+			Expect(err).ToNot(HaveOccurred())
+			os.Setenv("VENGO_CACHE", filepath.Join(root, "cache"))
+			cache.VenGO_PATH = filepath.Join(root, "home")
+			goroot := filepath.Join(root, "goroot")
+			Expect(os.MkdirAll(filepath.Join(goroot, "bin"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(goroot, "src", "fmt"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(goroot, "bin", "go"), []byte("go"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(goroot, "VERSION"), []byte("go1.4"), 0644)).To(Succeed())
+			_, err = cache.Adopt(nil, goroot, "go1.4", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.MkdirAll(filepath.Join(root, "cache2", "go1.4"), 0755)).To(Succeed())
+			e := env.NewEnvironment("relocateTest", "(relocateTest)")
+			Expect(e.Generate()).To(Succeed())
+			Expect(env.Relink("relocateTest", "go1.4")).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(root)
+			os.Setenv("VENGO_CACHE", "")
+			os.Setenv("VENGO_HOME", "")
+			cache.VenGO_PATH = vengoPath
+		})
+
+		It("Should move the cache and home and rewrite the environments", func() {
+			r := commands.NewRelocate(func(r *commands.Relocate) {
+				r.Cache = filepath.Join(root, "new", "cache")
+				r.Home = filepath.Join(root, "new", "home")
+			})
+			_, err := r.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cache.CacheDirectory()).To(Equal(r.Cache))
+			Expect(cache.VenGO_PATH).To(Equal(r.Home))
+
+			envPath := filepath.Join(r.Home, "relocateTest")
+			link, err := os.Readlink(filepath.Join(envPath, "lib"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(link).To(Equal(filepath.Join(r.Cache, "go1.4")))
+			activate, err := ioutil.ReadFile(filepath.Join(envPath, "bin", "activate"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(activate)).To(ContainSubstring(envPath))
+			Expect(string(activate)).ToNot(ContainSubstring(filepath.Join(root, "home")))
+			manifest := filepath.Join(r.Cache, "go1.4", ".vengo-manifest")
+			Expect(cache.CheckManifestIntegrity(manifest)).To(Succeed())
+			Expect(cache.AlreadyCompiled(nil, "go1.4")).To(BeTrue())
+		})
+
+		It("Should not rewrite the paths that only share the prefix", func() {
+			sibling := filepath.Join(root, "cache2", "go1.4")
+			library := filepath.Join(cache.VenGO_PATH, "relocateTest", "lib")
+			Expect(os.Remove(library)).To(Succeed())
+			Expect(os.Symlink(sibling, library)).To(Succeed())
+			r := commands.NewRelocate(func(r *commands.Relocate) {
+				r.Cache = filepath.Join(root, "new", "cache")
+			})
+			_, err := r.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			link, err := os.Readlink(library)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(link).To(Equal(sibling))
+		})
+	})
+
+	Describe("Uninstall", func() {
+		var cleanup func()
+
+		BeforeEach(func() {
+			_, cleanup = environmentSandbox("uninstallTest")
+			Expect(os.MkdirAll(cache.VersionPath("go1.5"), 0755)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			cleanup()
+		})
+
+		It("Should refuse to uninstall a version used by environments", func() {
+			u := commands.NewUninstall(func(u *commands.Uninstall) {
+				u.Version = "go1.4"
+			})
+			_, err := u.Run()
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("go1.4 is in use"))
+			Expect(cache.Exists("go1.4")).To(BeTrue())
+		})
+
+		It("Should uninstall a version used by environments with Force", func() {
+			u := commands.NewUninstall(func(u *commands.Uninstall) {
+				u.Version = "go1.4"
+				u.Force = true
+			})
+			_, err := u.Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cache.Exists("go1.4")).To(BeFalse())
+			linked, err := env.LinkedVersion("uninstallTest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(linked).To(Equal("go1.4"))
+		})
+
+		It("Should relink the environments with MigrateTo", func() {
+			u := commands.NewUninstall(func(u *commands.Uninstall) {
+				u.Version = "go1.4"
+				u.MigrateTo = "go1.5"
+			})
+			_, err := u.Run()
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cache.Exists("go1.4")).To(BeFalse())
+			linked, err := env.LinkedVersion("uninstallTest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(linked).To(Equal("go1.5"))
+			c, err := env.LoadConfig("uninstallTest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.GoVersion).To(Equal("go1.5"))
+		})
+	})
+
+	Describe("Setenv", func() {
+		var cleanup func()
+
+		BeforeEach(func() {
+			_, cleanup = environmentSandbox("setenvTest")
+		})
+
+		AfterEach(func() {
+			cleanup()
+		})
+
+		It("Should store the variables and regenerate the activate scripts", func() {
+			s := commands.NewSetenv(func(s *commands.Setenv) {
+				s.Environment = "setenvTest"
+				s.Set = map[string]string{"CGO_CFLAGS": "-I/opt/include"}
 			})
+			_, err := s.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			e, err := env.LoadEnvironment("setenvTest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(e.Env).To(Equal(map[string]string{"CGO_CFLAGS": "-I/opt/include"}))
+			activate, err := ioutil.ReadFile(filepath.Join(e.VenGO_PATH, "bin", "activate"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(activate)).To(ContainSubstring(`CGO_CFLAGS="-I/opt/include"`))
+
+			s = commands.NewSetenv(func(s *commands.Setenv) {
+				s.Environment = "setenvTest"
+				s.Unset = []string{"CGO_CFLAGS"}
+			})
+			_, err = s.Run()
+			Expect(err).ToNot(HaveOccurred())
+			activate, err = ioutil.ReadFile(filepath.Join(e.VenGO_PATH, "bin", "activate"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(activate)).ToNot(ContainSubstring("CGO_CFLAGS"))
+		})
+
+		It("Should refuse to set VenGO managed variables", func() {
+			s := commands.NewSetenv(func(s *commands.Setenv) {
+				s.Environment = "setenvTest"
+				s.Set = map[string]string{"GOROOT": "/usr/local/go"}
+			})
+			_, err := s.Run()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Hook", func() {
+		var home string
+		var cleanup func()
+
+		BeforeEach(func() {
+			_, cleanup = environmentSandbox("hookTest")
+			home = cache.VenGO_PATH
+		})
+
+		AfterEach(func() {
+			cleanup()
+		})
+
+		It("Should create, list and remove hook scripts", func() {
+			edit := func(h *commands.Hook) {
+				h.Action = "edit"
+				h.Environment = "hookTest"
+				h.Name = "postactivate"
+				h.Editor = "true"
+			}
+			_, err := commands.NewHook(edit).Run()
+			Expect(err).ToNot(HaveOccurred())
+			script := filepath.Join(home, "hookTest", "hooks", "postactivate")
+			Expect(os.Stat(script)).ToNot(BeNil())
+
+			_, err = commands.NewHook(edit, func(h *commands.Hook) {
+				h.Global = true
+				h.Shell = "fish"
+			}).Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.Stat(filepath.Join(home, "hooks", "postactivate.fish"))).ToNot(BeNil())
+
+			list, err := commands.NewHook(func(h *commands.Hook) {
+				h.Action = "list"
+				h.Environment = "hookTest"
+			}).Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list).To(ContainSubstring(script))
+			Expect(list).To(ContainSubstring(filepath.Join(home, "hooks", "postactivate.fish")))
+
+			_, err = commands.NewHook(edit, func(h *commands.Hook) {
+				h.Action = "rm"
+			}).Run()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = os.Stat(script)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("Should fail with unknown hooks", func() {
+			_, err := commands.NewHook(func(h *commands.Hook) {
+				h.Action = "edit"
+				h.Environment = "hookTest"
+				h.Name = "postinstall"
+				h.Editor = "true"
+			}).Run()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Templates", func() {
+		It("Should dump the built in templates without overwrite them", func() {
+			root, err := ioutil.TempDir("", "VenGOTemplatesTest")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(root)
+			Expect(ioutil.WriteFile(
+				filepath.Join(root, "activate"), []byte("custom"), 0644)).To(Succeed())
+
+			t := commands.NewTemplates(func(t *commands.Templates) {
+				t.Directory = root
+			})
+			output, err := t.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(ContainSubstring("activate already exist"))
+			for _, name := range env.Templates() {
+				Expect(os.Stat(filepath.Join(root, name))).ToNot(BeNil())
+			}
+			custom, err := ioutil.ReadFile(filepath.Join(root, "activate"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(custom)).To(Equal("custom"))
+
+			t.Force = true
+			_, err = t.Run()
+			Expect(err).ToNot(HaveOccurred())
+			builtin, err := env.DefaultTemplate("activate")
+			Expect(err).ToNot(HaveOccurred())
+			custom, err = ioutil.ReadFile(filepath.Join(root, "activate"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(custom).To(Equal(builtin))
+		})
+	})
+
+	Describe("Exec", func() {
+		var root string
+		var cleanup func()
+
+		BeforeEach(func() {
+			root, cleanup = environmentSandbox("execTest", func(e *env.Environment) {
+				Expect(e.SetVariable("EXEC_TEST", "custom")).To(Succeed())
+			})
+		})
+
+		AfterEach(func() {
+			cleanup()
+		})
+
+		It("Should run the command in the environment and return its exit code", func() {
+			output := filepath.Join(root, "output")
+			e := commands.NewExec(func(e *commands.Exec) {
+				e.Environment = "execTest"
+				e.Command = []string{"sh", "-c",
+					`echo "$GOROOT $GOPATH $EXEC_TEST" > ` + output + "; exit 3"}
+			})
+			_, err := e.Run()
+			var exit *commands.ExitError
+			Expect(errors.As(err, &exit)).To(BeTrue())
+			Expect(exit.Code).To(Equal(3))
+
+			envPath := filepath.Join(cache.VenGO_PATH, "execTest")
+			data, err := ioutil.ReadFile(output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(fmt.Sprintf(
+				"%s %s custom\n", filepath.Join(envPath, "lib"), envPath)))
+			Expect(os.Getenv("EXEC_TEST")).To(BeEmpty())
+		})
+
+		It("Should run the command when the Go version is not available", func() {
+			library := filepath.Join(cache.VenGO_PATH, "execTest", "lib")
+			Expect(os.RemoveAll(filepath.Join(cache.CacheDirectory(), "go1.4"))).To(Succeed())
+			e := commands.NewExec(func(e *commands.Exec) {
+				e.Environment = "execTest"
+				e.Command = []string{"true"}
+			})
+			_, err := e.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(os.Remove(library)).To(Succeed())
+			_, err = e.Run()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("Shell", func() {
+		var root string
+		var cleanup func()
+
+		BeforeEach(func() {
+			root, cleanup = environmentSandbox("shellTest", func(e *env.Environment) {
+				Expect(e.SetShells([]string{"fish"})).To(Succeed())
+			})
+		})
+
+		AfterEach(func() {
+			cleanup()
+		})
+
+		It("Should start the shell with the environment activated", func() {
+			output := filepath.Join(root, "output")
+			hook := env.GlobalHookPath("postactivate")
+			Expect(os.MkdirAll(filepath.Dir(hook), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(hook, []byte(
+				`echo "$VENGO_SHELL $GOPATH" > `+output+"\nexit 3\n"), 0644)).To(Succeed())
+
+			s := commands.NewShell(func(s *commands.Shell) {
+				s.Environment = "shellTest"
+				s.Shell, s.Path = "bash", ""
+			})
+			_, err := s.Run()
+			var exit *commands.ExitError
+			Expect(errors.As(err, &exit)).To(BeTrue())
+			Expect(exit.Code).To(Equal(3))
+
+			envPath := filepath.Join(cache.VenGO_PATH, "shellTest")
+			data, err := ioutil.ReadFile(output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("shellTest " + envPath + "\n"))
+			_, err = os.Stat(filepath.Join(envPath, "bin", "activate"))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Should fail with unsupported shells", func() {
+			s := commands.NewShell(func(s *commands.Shell) {
+				s.Environment = "shellTest"
+				s.Shell, s.Path = "csh", "/bin/csh"
+			})
+			_, err := s.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not supported"))
+		})
+	})
+
+	Describe("Which", func() {
+		It("Should return the environment of the nearest marker file", func() {
+			project, err := ioutil.TempDir("", "VenGOWhichTest")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(project)
+			marker := &env.Marker{Environment: "whichTest", GoVersion: "go1.4"}
+			Expect(marker.Save(project)).To(Succeed())
+			subdir := filepath.Join(project, "src")
+			Expect(os.MkdirAll(subdir, 0755)).To(Succeed())
+
+			w := commands.NewWhich(func(w *commands.Which) { w.Directory = subdir })
+			name, err := w.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("whichTest"))
+
+			w = commands.NewWhich(func(w *commands.Which) { w.Directory = os.TempDir() })
+			_, err = w.Run()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("HookShell", func() {
+		It("Should generate the shell integration running the given binary", func() {
+			for _, shell := range []string{"bash", "zsh", "fish"} {
+				h := commands.NewHookShell(func(h *commands.HookShell) {
+					h.Shell = shell
+					h.Vengo = "/opt/it's/$vengo"
+				})
+				script, err := h.Run()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(script).To(ContainSubstring("which --activate=" + shell))
+				Expect(script).To(ContainSubstring("_vengo_hook"))
+			}
+			h := commands.NewHookShell(func(h *commands.HookShell) {
+				h.Shell = "bash"
+				h.Vengo = "/opt/it's/$vengo"
+			})
+			script, _ := h.Run()
+			Expect(script).To(ContainSubstring(`"/opt/it's/\$vengo" --quiet which`))
+		})
+
+		It("Should fail with unsupported shells", func() {
+			h := commands.NewHookShell(func(h *commands.HookShell) { h.Shell = "csh" })
+			_, err := h.Run()
+			Expect(err).To(HaveOccurred())
 		})
 	})
 
@@ -640,4 +1134,56 @@ var _ = Describe("Commands", func() {
 			Expect(e.Exists()).To(BeFalse())
 		})
 	})
+	Describe("Help", func() {
+		It("Should return ErrUsage for unknown commands", func() {
+			Expect(commands.Help("dontExists")).To(Equal(commands.ErrUsage))
+		})
+
+		It("Should display the help of known commands", func() {
+			Expect(commands.Help("rmenv")).To(Succeed())
+		})
+	})
+
+	Describe("Usage", func() {
+		It("Should return ErrUsage after display the usage", func() {
+			Expect(commands.Usage()).To(Equal(commands.ErrUsage))
+		})
+	})
+
+	Describe("NewLogger", func() {
+		It("Should configure the logger with the given flags", func() {
+			log, err := commands.NewLogger(true, false, "json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(log.Level).To(Equal(logger.Error))
+			Expect(log.Format).To(Equal(logger.Json))
+
+			log, err = commands.NewLogger(false, true, "text")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(log.Level).To(Equal(logger.Debug))
+			Expect(log.Format).To(Equal(logger.Text))
+		})
+
+		It("Should fail with invalid flags", func() {
+			_, err := commands.NewLogger(true, true, "text")
+			Expect(err).To(HaveOccurred())
+			_, err = commands.NewLogger(false, false, "xml")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should emit steps as json events", func() {
+			buf := new(bytes.Buffer)
+			log := logger.New(func(l *logger.Logger) {
+				l.Format = logger.Json
+				l.Writer = buf
+			})
+			log.Step("Checking", "version", "go1.4").Fail(errors.New("boom"))
+			event := map[string]interface{}{}
+			Expect(json.Unmarshal(buf.Bytes(), &event)).To(Succeed())
+			Expect(event["msg"]).To(Equal("Checking"))
+			Expect(event["level"]).To(Equal("error"))
+			Expect(event["status"]).To(Equal("failed"))
+			Expect(event["error"]).To(Equal("boom"))
+			Expect(event["version"]).To(Equal("go1.4"))
+		})
+	})
 })
@@ -0,0 +1,119 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+var cmdExec = &Command{
+	Name:  "exec",
+	Usage: "exec env_name -- command [args...]",
+	Short: "Run a command inside an environment without activating it",
+	Long: `Runs the given command with the environment GOROOT, GOTOOLDIR, GOPATH, PATH
+and custom variables applied, without sourcing any activate script. The exit
+code of vengo is the exit code of the command, so it can be used from CI
+scripts and Makefiles, for example:
+
+    vengo exec myenv -- go test ./...
+
+Use '--' to separate the command from the vengo flags. Hooks are not run as
+they are shell scripts meant to be sourced by the shell.
+`,
+	Execute: runExec,
+}
+
+// initialize the command
+func init() {
+	cmdExec.Flag.SetInterspersed(false)
+	cmdExec.register()
+}
+
+// run the exec command
+func runExec(cmd *Command, args ...string) error {
+	if len(args) < 2 {
+		return ErrUsage
+	}
+	command := args[1:]
+	// flags are not parsed after the environment name so the separator and
+	// the command flags are passed through untouched
+	if command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) == 0 {
+		return ErrUsage
+	}
+	e := NewExec(func(e *Exec) {
+		e.Environment = args[0]
+		e.Command = command
+		e.Log = cmd.Log
+	})
+	_, err := e.Run()
+	return err
+}
+
+// exec command
+type Exec struct {
+	Environment string
+	Command     []string
+	Log         *logger.Logger
+}
+
+// Create a new exec command and return back it's address
+func NewExec(options ...func(e *Exec)) *Exec {
+	e := new(Exec)
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
+
+// implements the Runner interface running the command in the environment,
+// if the command fails an *ExitError with its exit code is returned
+func (e *Exec) Run() (string, error) {
+	environment, err := loadRestoredEnvironment(e.Environment, e.Log)
+	if err != nil {
+		return "", err
+	}
+
+	environment.Activate()
+	defer environment.Deactivate()
+	command := exec.Command(e.Command[0], e.Command[1:]...)
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return "", exitError(command.Run())
+}
+
+// convert the error of a finished program into an *ExitError with its exit
+// code, other errors are returned back as they are
+func exitError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			// killed by a signal
+			code = 1
+		}
+		return &ExitError{Code: code}
+	}
+	return err
+}
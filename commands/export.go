@@ -26,16 +26,12 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
-	"path"
 	"path/filepath"
-	"regexp"
-	"strings"
 
 	"github.com/DamnWidget/VenGO/cache"
 	"github.com/DamnWidget/VenGO/env"
-	"github.com/DamnWidget/VenGO/utils"
+	"github.com/DamnWidget/VenGO/logger"
 )
 
 var cmdExport = &Command{
@@ -73,12 +69,12 @@ func init() {
 }
 
 // run the export command
-func runExport(cmd *Command, args ...string) {
+func runExport(cmd *Command, args ...string) error {
 	env := func(e *Export) {}
 	if len(args) == 0 {
 		activeEnv := os.Getenv("VENGO_ENV")
 		if activeEnv == "" {
-			cmd.DisplayUsageAndExit()
+			return ErrUsage
 		}
 	} else {
 		env = func(e *Export) {
@@ -89,26 +85,21 @@ func runExport(cmd *Command, args ...string) {
 		e.Force = forceExport
 		e.Prettify = prettifyExport
 		e.Name = nameExport
+		e.Log = cmd.Log
 	}
 	e := NewExport(options, env)
 	if e.Err() != nil {
-		fmt.Println(utils.Fail(fmt.Sprint(e.Err())))
-		os.Exit(2)
+		return e.Err()
 	}
 	if e.Exists() {
 		if !e.Force {
 			p := filepath.Join(e.Environment, e.Name)
-			fmt.Println(utils.Fail(fmt.Sprintf("%s already exists", p)))
-			fmt.Printf("%s: use the -f option to overwrite it\n", suggest)
-			os.Exit(2)
+			return suggestError(fmt.Errorf("%s already exists", p),
+				"use the -f option to overwrite it")
 		}
 	}
 	_, err := e.Run()
-	if err != nil {
-		fmt.Println(utils.Fail(fmt.Sprintf("error: %v", err)))
-		os.Exit(2)
-	}
-	os.Exit(0)
+	return err
 }
 
 // export command
@@ -117,6 +108,7 @@ type Export struct {
 	Name        string
 	Force       bool
 	Prettify    bool
+	Log         *logger.Logger
 	err         error
 }
 
@@ -137,39 +129,41 @@ func (e *Export) Run() (string, error) {
 
 // export the given environment using a VenGO.manifest file
 func (e *Export) envExport() (string, error) {
-	fmt.Print("Loading environment... ")
+	step := e.Log.Step("Loading environment")
 	environment, err := e.LoadEnvironment()
 	if err != nil {
-		fmt.Println(utils.Fail("✖"))
+		step.Fail(err)
 		return "", err
 	}
-	fmt.Println(utils.Ok("✔"))
-	fmt.Print("Generating manifest... ")
+	step.Ok()
+	step = e.Log.Step("Generating manifest")
 	environManifest, err := environment.Manifest()
 	if err != nil {
-		fmt.Println(utils.Fail("✖"))
+		step.Fail(err)
 		return "", err
 	}
 	manifest, err := environManifest.Generate()
 	if err != nil {
-		fmt.Println(utils.Fail("✖"))
+		step.Fail(err)
 		return "", err
 	}
-	fmt.Println(utils.Ok("✔"))
+	step.Ok()
 	if e.Prettify {
 		buffer := new(bytes.Buffer)
 		json.Indent(buffer, manifest, "", "\t")
 		manifest = buffer.Bytes()
 	}
-	fmt.Printf("Writing manifest into %s... ", environment.VenGO_PATH)
+	step = e.Log.Step(
+		fmt.Sprintf("Writing manifest into %s", environment.VenGO_PATH))
 	err = ioutil.WriteFile(
 		filepath.Join(environment.VenGO_PATH, e.Name), manifest, 0644)
 	if err != nil {
-		fmt.Println(utils.Fail("✖"))
+		step.Fail(err)
+		return "", err
 	}
-	fmt.Println(utils.Ok("✔"))
+	step.Ok()
 
-	return "", err
+	return "", nil
 }
 
 // normalize an export configuration, if there is no environment, try to detect
@@ -195,38 +189,21 @@ func (e *Export) Err() error {
 	return e.err
 }
 
-// load environment using the activate environment script, return an error
-// if the operation can't be completed
+// load environment using its configuration file, return an error if the
+// operation can't be completed
 func (e *Export) LoadEnvironment() (*env.Environment, error) {
-	readFile := func(filename string) ([]byte, error) {
-		return ioutil.ReadFile(filename)
-	}
-	filenames := [2]string{
-		filepath.Join(e.Environment, "bin", "activate"),
-		filepath.Join(cache.VenGO_PATH, e.Environment, "bin", "activate"),
-	}
-	var err error
-	var activateFile []byte
-	for _, filename := range filenames {
-		activateFile, err = readFile(filename)
-		if err == nil {
-			break
-		}
-	}
+	environment, err := env.LoadEnvironment(e.Environment)
 	if err != nil {
 		return nil, err
 	}
-	byteLines := bytes.Split(activateFile, []byte("\n"))
-	re := regexp.MustCompile(`"(.*?) `)
-	prompt := strings.TrimRight(strings.TrimLeft(
-		re.FindAllString(string(byteLines[86]), 1)[0], `"`), " ")
-	environment := env.NewEnvironment(path.Base(e.Environment), prompt)
+	environment.Log = e.Log
 	return environment, nil
 }
 
 // check if a manifest already exists for the given environment
 func (e *Export) Exists() bool {
-	log.Println(filepath.Join(e.Environment, e.Name))
+	e.Log.Debug("checking for an existing manifest",
+		"path", filepath.Join(e.Environment, e.Name))
 	_, err := os.Stat(filepath.Join(e.Environment, e.Name))
 	return err == nil
 }
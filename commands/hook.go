@@ -0,0 +1,294 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/utils"
+)
+
+var cmdHook = &Command{
+	Name:  "hook",
+	Usage: "hook list [env] | edit [-g] [--shell name] [--path script] [env] hook | rm [-g] [--shell name] [env] hook",
+	Short: "Manage the environments lifecycle hooks",
+	Long: `Manages the scripts that are run when environments are activated or
+deactivated. The available hooks are preactivate, postactivate, predeactivate
+and postdeactivate. Hook scripts are sourced by the shell, so they can change
+directory, define functions or set variables. The available operations are:
+
+  list      Shows the global hooks and the hooks of the given environment.
+
+  edit      Opens the hook script of the given environment with $EDITOR,
+            creating it if it doesn't exist. With -g or --global the global
+            hook, that runs for every environment, is edited instead. With
+            --path the environment uses the given script as hook instead of
+            the one in its hooks directory.
+
+  rm        Removes the hook script of the given environment or the global
+            one if -g or --global is passed. Environments using a script set
+            with --path go back to their hooks directory, the script is kept.
+
+The hook scripts of bash and zsh are shared, other shells use their own
+scripts with the .fish, .csh or .ps1 extensions, use the --shell flag to edit
+or remove them. Nushell environments can't run hooks. For example:
+
+    vengo hook edit myenv postactivate
+    vengo hook edit --shell fish myenv postactivate
+    vengo hook edit -g predeactivate
+`,
+	Execute: runHook,
+}
+
+var (
+	globalHook bool
+	shellHook  string
+	pathHook   string
+)
+
+// initialize the command
+func init() {
+	cmdHook.Flag.BoolVarP(&globalHook, "global", "g", false, "global hook")
+	cmdHook.Flag.StringVar(&shellHook, "shell", "bash", "shell")
+	cmdHook.Flag.StringVar(&pathHook, "path", "", "hook script")
+	cmdHook.register()
+}
+
+// run the hook command
+func runHook(cmd *Command, args ...string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	hook := NewHook(func(h *Hook) {
+		h.Action = args[0]
+		h.Global = globalHook
+		h.Shell = shellHook
+		h.Path = pathHook
+	})
+	switch {
+	case hook.Action == "list" && len(args) <= 2:
+		if len(args) == 2 {
+			hook.Environment = args[1]
+		}
+	case hook.Action == "edit" || hook.Action == "rm":
+		if hook.Global && len(args) == 2 {
+			hook.Name = args[1]
+		} else if !hook.Global && len(args) == 3 {
+			hook.Environment, hook.Name = args[1], args[2]
+		} else {
+			return ErrUsage
+		}
+	default:
+		return ErrUsage
+	}
+	data, err := hook.Run()
+	if err != nil {
+		return err
+	}
+	if data != "" {
+		fmt.Println(data)
+	}
+	return nil
+}
+
+// hook command
+type Hook struct {
+	Action      string
+	Environment string
+	Name        string
+	Global      bool
+	Shell       string
+	Path        string
+	Editor      string
+}
+
+// Create a new hook command and return back it's address
+func NewHook(options ...func(h *Hook)) *Hook {
+	hook := new(Hook)
+	for _, option := range options {
+		option(hook)
+	}
+	if hook.Shell == "" {
+		hook.Shell = "bash"
+	}
+	if hook.Editor == "" {
+		hook.Editor = os.Getenv("EDITOR")
+		if hook.Editor == "" {
+			hook.Editor = "vi"
+		}
+	}
+	return hook
+}
+
+// implements the Runner interface running the hook operation
+func (h *Hook) Run() (string, error) {
+	switch h.Action {
+	case "list":
+		return h.list()
+	case "edit":
+		return h.edit()
+	case "rm":
+		return h.remove()
+	}
+	return "", fmt.Errorf("%s is not a valid hook operation", h.Action)
+}
+
+// list the global hooks and the ones of the environment
+func (h *Hook) list() (string, error) {
+	output := []string{utils.Ok("Global hooks")}
+	output = append(output, listHooks(env.GlobalHookPath)...)
+	if h.Environment != "" {
+		environment, err := env.LoadEnvironment(h.Environment)
+		if err != nil {
+			return "", fmt.Errorf(
+				"%s is not a VenGO environment: %v", h.Environment, err)
+		}
+		output = append(output,
+			utils.Ok(fmt.Sprintf("%s environment hooks", environment.Name)))
+		output = append(output, listHooks(environment.HookPath)...)
+	}
+	return strings.Join(output, "\n"), nil
+}
+
+// edit the hook script with the configured editor
+func (h *Hook) edit() (string, error) {
+	environment, err := h.environment()
+	if err != nil {
+		return "", err
+	}
+	if h.Path != "" {
+		if h.Global {
+			return "", fmt.Errorf("--path can't be used with global hooks")
+		}
+		script, err := filepath.Abs(h.Path)
+		if err != nil {
+			return "", err
+		}
+		if err := environment.SetHook(h.Name, script); err != nil {
+			return "", err
+		}
+		if err := environment.Generate(); err != nil {
+			return "", err
+		}
+	}
+	script, err := h.script(environment)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(script); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(script), 0755); err != nil {
+			return "", err
+		}
+		scope := "every environment"
+		if !h.Global {
+			scope = fmt.Sprintf("the %s environment", environment.Name)
+		}
+		header := fmt.Sprintf(
+			"# %s hook for %s, this script is sourced by the shell\n",
+			h.Name, scope)
+		if err := ioutil.WriteFile(script, []byte(header), 0644); err != nil {
+			return "", err
+		}
+	}
+	editor := strings.Fields(h.Editor)
+	cmd := exec.Command(editor[0], append(editor[1:], script)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("while editing %s: %v", script, err)
+	}
+	return "", nil
+}
+
+// remove the hook script or the script set with --path
+func (h *Hook) remove() (string, error) {
+	environment, err := h.environment()
+	if err != nil {
+		return "", err
+	}
+	if !h.Global {
+		if _, ok := environment.Hooks[h.Name]; ok {
+			environment.SetHook(h.Name, "")
+			if err := environment.Generate(); err != nil {
+				return "", err
+			}
+			return utils.Ok(fmt.Sprintf(
+				"%s uses its own %s hook again", environment.Name, h.Name)), nil
+		}
+	}
+	script, err := h.script(environment)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(script); err != nil {
+		return "", err
+	}
+	return utils.Ok(fmt.Sprintf("%s has been removed", script)), nil
+}
+
+// validate the hook name and load the environment if the hook isn't global
+func (h *Hook) environment() (*env.Environment, error) {
+	if !env.IsHook(h.Name) {
+		return nil, suggestError(
+			fmt.Errorf("%s is not a valid hook", h.Name),
+			"use one of %s", strings.Join(env.Hooks, ", "))
+	}
+	if h.Global {
+		return nil, nil
+	}
+	environment, err := env.LoadEnvironment(h.Environment)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s is not a VenGO environment: %v", h.Environment, err)
+	}
+	return environment, nil
+}
+
+// return back the hook script path for the shell
+func (h *Hook) script(environment *env.Environment) (string, error) {
+	extension, err := env.HookExtension(h.Shell)
+	if err != nil {
+		return "", err
+	}
+	if h.Global {
+		return env.GlobalHookPath(h.Name) + extension, nil
+	}
+	return environment.HookPath(h.Name) + extension, nil
+}
+
+// generate the list of existing hook scripts using the given path function
+func listHooks(path func(string) string) []string {
+	output := []string{}
+	for _, hook := range env.Hooks {
+		for _, extension := range env.HookExtensions() {
+			script := path(hook) + extension
+			if _, err := os.Stat(script); err == nil {
+				output = append(output, fmt.Sprintf("    %-16s%s", hook, script))
+			}
+		}
+	}
+	return output
+}
@@ -0,0 +1,180 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/DamnWidget/VenGO/env"
+)
+
+var cmdHookShell = &Command{
+	Name:  "hook-shell",
+	Usage: "hook-shell bash|zsh|fish",
+	Short: "Print the shell integration that activates environments on cd",
+	Long: `Prints a shell script that activates the environment of the current
+directory every time the directory changes and deactivates it when leaving
+it. The environment is looked up with 'vengo which' using the nearest .vengo
+or .go-version file, so the VenGO binary only runs when the directory changes.
+Environments activated by hand are left alone. Load it from the shell startup
+file:
+
+    eval "$(vengo hook-shell bash)"      # ~/.bashrc
+    eval "$(vengo hook-shell zsh)"       # ~/.zshrc
+    vengo hook-shell fish | source       # ~/.config/fish/config.fish
+`,
+	Execute: runHookShell,
+}
+
+// initialize the command
+func init() {
+	cmdHookShell.register()
+}
+
+// run the hook-shell command
+func runHookShell(cmd *Command, args ...string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+	h := NewHookShell(func(h *HookShell) { h.Shell = args[0] })
+	data, err := h.Run()
+	if err != nil {
+		return err
+	}
+	fmt.Print(data)
+	return nil
+}
+
+// bash and zsh share the hook function, bash runs it from PROMPT_COMMAND
+// and zsh when the directory changes
+const posixHookShell = `# VenGO automatic environments activation for {{ .Shell }}
+_vengo_hook() {
+    if [ "$PWD" = "$_VENGO_HOOK_PWD" ]; then
+        return
+    fi
+    _VENGO_HOOK_PWD="$PWD"
+    local script
+    script="$({{ .Vengo }} --quiet which --activate={{ .Shell }} 2>/dev/null)" || script=""
+    if [ "$script" = "$_VENGO_AUTO_SCRIPT" ]; then
+        return
+    fi
+    # deactivate the environment activated by the hook if it is still active
+    if [ -n "$_VENGO_AUTO_ENV" ] && [ "$VENGO_ENV" = "$_VENGO_AUTO_ENV" ]; then
+        deactivate
+    fi
+    _VENGO_AUTO_SCRIPT=""
+    _VENGO_AUTO_ENV=""
+    # environments activated by hand are left alone
+    if [ -n "$script" ] && [ -z "$VENGO_ENV" ]; then
+        source "$script"
+        _VENGO_AUTO_SCRIPT="$script"
+        _VENGO_AUTO_ENV="$VENGO_ENV"
+    fi
+}
+`
+
+// bash hook registration
+const bashHookShell = posixHookShell + `
+if [[ ";${PROMPT_COMMAND:-};" != *";_vengo_hook;"* ]]; then
+    PROMPT_COMMAND="_vengo_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+// zsh hook registration
+const zshHookShell = posixHookShell + `
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _vengo_hook
+_vengo_hook
+`
+
+// fish runs the hook function when PWD changes
+const fishHookShell = `# VenGO automatic environments activation for fish
+function _vengo_hook --on-variable PWD --description "Activate the VenGO environment of the current directory"
+    set -l script ({{ .Vengo }} --quiet which --activate=fish 2>/dev/null); or set script ""
+    if test "$script" = "$_VENGO_AUTO_SCRIPT"
+        return
+    end
+    # deactivate the environment activated by the hook if it is still active
+    if test -n "$_VENGO_AUTO_ENV"; and test "$VENGO_ENV" = "$_VENGO_AUTO_ENV"
+        deactivate
+    end
+    set -g _VENGO_AUTO_SCRIPT ""
+    set -g _VENGO_AUTO_ENV ""
+    # environments activated by hand are left alone
+    if test -n "$script"; and not set -q VENGO_ENV
+        source "$script"
+        set -g _VENGO_AUTO_SCRIPT "$script"
+        set -g _VENGO_AUTO_ENV "$VENGO_ENV"
+    end
+end
+_vengo_hook
+`
+
+// hook-shell command
+type HookShell struct {
+	Shell string
+	Vengo string
+}
+
+// Create a new hook-shell command and return back it's address, the
+// integration runs the current vengo binary if no other one is given
+func NewHookShell(options ...func(h *HookShell)) *HookShell {
+	h := new(HookShell)
+	for _, option := range options {
+		option(h)
+	}
+	if h.Vengo == "" {
+		h.Vengo = "vengo"
+		if executable, err := os.Executable(); err == nil {
+			h.Vengo = executable
+		}
+	}
+	return h
+}
+
+// implements the Runner interface returning back the shell integration
+func (h *HookShell) Run() (string, error) {
+	var script string
+	quote := env.QuotePosix
+	switch h.Shell {
+	case "bash":
+		script = bashHookShell
+	case "zsh":
+		script = zshHookShell
+	case "fish":
+		script, quote = fishHookShell, env.QuoteFish
+	default:
+		return "", suggestError(
+			fmt.Errorf("%s is not supported by hook-shell", h.Shell),
+			"use bash, zsh or fish")
+	}
+	tpl, err := template.New(h.Shell).Parse(script)
+	if err != nil {
+		return "", err
+	}
+	buffer := new(bytes.Buffer)
+	err = tpl.Execute(buffer, map[string]string{
+		"Shell": h.Shell, "Vengo": quote(h.Vengo)})
+	return buffer.String(), err
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/DamnWidget/VenGO/cache"
 	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/DamnWidget/VenGO/utils"
 )
 
@@ -68,15 +69,13 @@ func init() {
 }
 
 // run the import command
-func runImport(cmd *Command, args ...string) {
+func runImport(cmd *Command, args ...string) error {
 	if len(args) == 0 {
-		cmd.DisplayUsageAndExit()
+		return ErrUsage
 	}
 	// make sure that the manifest file exists
 	if _, err := os.Stat(args[0]); err != nil {
-		fmt.Println(utils.Fail(
-			fmt.Sprintf("error: can't open %s manifest file", args[0])))
-		os.Exit(2)
+		return fmt.Errorf("can't open %s manifest file", args[0])
 	}
 
 	options := func(i *Import) {
@@ -84,15 +83,15 @@ func runImport(cmd *Command, args ...string) {
 		i.Verbose = verboseImport
 		i.Prompt = promptImport
 		i.Manifest = args[0]
+		i.Log = cmd.Log
 	}
 	i := NewImport(options)
 	out, err := i.Run()
 	if err != nil {
-		fmt.Println(utils.Fail(fmt.Sprintf("error: %v", err)))
-		os.Exit(2)
+		return err
 	}
 	fmt.Printf("%s\n", utils.Ok(out))
-	os.Exit(0)
+	return nil
 }
 
 type Import struct {
@@ -100,6 +99,7 @@ type Import struct {
 	Prompt   string
 	Verbose  bool
 	Force    bool
+	Log      *logger.Logger
 }
 
 // create a new import command and return back it's address
@@ -118,7 +118,7 @@ func (i *Import) Run() (string, error) {
 
 // import the given manifest and create a new environment based on it
 func (i *Import) envImport() (string, error) {
-	fmt.Printf("Loading manifest file %s... \n", i.Manifest)
+	i.Log.Info(fmt.Sprintf("Loading manifest file %s...", i.Manifest))
 	manifest, err := env.LoadManifest(i.Manifest)
 	if err != nil {
 		return "", err
@@ -127,8 +127,9 @@ func (i *Import) envImport() (string, error) {
 	if err == nil && !i.Force {
 		return "", errors.New("environment already exists")
 	}
-	fmt.Printf("Creating %s environment...\n", filepath.Join(cache.VenGO_PATH, manifest.Name))
-	err = manifest.GenerateEnvironment(i.Verbose, i.Prompt)
+	i.Log.Info(fmt.Sprintf("Creating %s environment...",
+		filepath.Join(cache.VenGO_PATH, manifest.Name)))
+	err = manifest.GenerateEnvironment(i.Log, i.Verbose, i.Prompt)
 	if err != nil {
 		return "", err
 	}
@@ -21,17 +21,21 @@
 package commands
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
 
 	"github.com/DamnWidget/VenGO/cache"
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/DamnWidget/VenGO/utils"
 )
 
 var cmdInstall = &Command{
 	Name:  "install",
-	Usage: "install [-s] [-b] [-v] [-f] [-n] [-x] version",
+	Usage: "install [-s] [-b] [-v] [-f] [-n] [-x] [-j jobs] [--compile-jobs jobs] [--os os] [--arch arch] [--require-signature] [--dedupe] [--from source] [--repo url --ref ref --name name] version [version...]",
 	Short: "Installs a new Go version",
 	Long: `Install a new version of Go, it can be installed directly from the official
 mercurial or git repositories, from a tarball packaed source or directly in
@@ -41,6 +45,26 @@ If the -s or --source flag is passed, a compressed tarball is downloaded and
 compiled. If the -b or --binary flag is used, an already compiled tarball is
 used.
 
+Binaries for other operating systems or architectures can be installed using
+the --os and --arch flags together with -b or --binary, they are stored in the
+cache using their platform qualified name (e.g. 1.4.linux-386):
+
+    vengo install -b --os linux --arch 386 1.4
+
+Only the platforms with official binaries of the version can be installed,
+linux, darwin, freebsd and (up to 1.3.3) windows on 386 and amd64. There are
+no official binaries of these releases for arm, the installation fails before
+anything is downloaded listing the platforms available for the version.
+
+Downloaded tarballs are verified against their detached OpenPGP signature
+when gpg is installed and an OpenPGP keyring is found, the path given in the
+VENGO_KEYRING environment variable, keyring.gpg in the VenGO home or the Go
+releases keyring that the installer bundles in share/keyring.gpg. The
+fingerprint of the key that signed the release is recorded in the
+installation metadata. With the --require-signature flag the installation
+fails if the signature can't be verified, versions from git or other
+repositories have no signature so they are rejected before downloading.
+
 If the given version is already installed, we can force it's reinstallation
 using the -f or --force flags, to compile the newly downloaded Go version
 with CGO_ENABLED=0 the -n or --ncgo flag should be passed.
@@ -48,6 +72,32 @@ with CGO_ENABLED=0 the -n or --ncgo flag should be passed.
 The -x or -bootstrap flag is used to compile go 1.5 and superior, you should
 pass the path of a valid go 1.4 instalation as value for this parameter.
 
+Go can also be installed from any git repository or fork (including a path to
+a local repository) using the --repo flag. The branch, tag or commit to build
+is given with the --ref flag and the name used to register it in the cache
+with the --name flag (the version argument is used if --name is not given):
+
+    vengo install --repo https://example.com/go.git --ref my-branch --name go-fork
+
+The repository URL and the resolved commit are recorded in the installation
+metadata.
+
+If the --dedupe flag is passed, files of the newly installed version that are
+identical to files already in the deduplication store are replaced with
+hardlinks (see 'vengo help cache').
+
+The --from flag installs the version using any of the installation sources
+registered in VenGO by name (git, tarball, binary or repository), the
+source used is recorded in the installation metadata.
+
+Several versions can be installed at the same time passing all of them to the
+install command, the downloads run concurrently limited by the -j or --jobs
+flag (1 by default) while the compilations are limited separately by the
+--compile-jobs flag (1 by default). A table with the result of each version is
+shown when all the installations are done:
+
+    vengo install -j 3 1.3.3 1.4.2 1.5
+
 Use the -v or --verbose flags to run the command with verbose output, this
 is useful to debug in case of errors during the compilation phase.
 `,
@@ -61,6 +111,16 @@ var (
 	verboseInstall bool
 	nocgoInstall   bool
 	bootStrap      string
+	repoInstall    string
+	refInstall     string
+	nameInstall    string
+	dedupeInstall  bool
+	fromInstall    string
+	osInstall      string
+	archInstall    string
+	signedInstall  bool
+	jobsInstall    int
+	compileInstall int
 )
 
 // possible installation sources
@@ -68,8 +128,17 @@ const (
 	Mercurial = iota
 	Source
 	Binary
+	Repository
 )
 
+// names of the installation sources registered in the cache
+var sourceNames = map[int]string{
+	Mercurial:  "git",
+	Source:     "tarball",
+	Binary:     "binary",
+	Repository: "repository",
+}
+
 // install command
 type Install struct {
 	Force     bool
@@ -79,6 +148,15 @@ type Install struct {
 	Verbose   bool
 	NoCGO     bool
 	BootStrap string
+	Repo      string
+	Ref       string
+	Name      string
+	Dedupe    bool
+	From      string
+	OS        string
+	Arch      string
+	Signed    bool
+	Log       *logger.Logger
 }
 
 // initialize the command
@@ -89,19 +167,40 @@ func init() {
 	cmdInstall.Flag.BoolVarP(&verboseInstall, "verbose", "v", false, "verbose output")
 	cmdInstall.Flag.BoolVarP(&nocgoInstall, "ncgo", "n", false, "CGO_ENABLE=0")
 	cmdInstall.Flag.StringVarP(&bootStrap, "bootstrap", "x", "", "booostrap cmd ")
+	cmdInstall.Flag.StringVar(&repoInstall, "repo", "", "git repository")
+	cmdInstall.Flag.StringVar(&refInstall, "ref", "", "git reference")
+	cmdInstall.Flag.StringVar(&nameInstall, "name", "", "custom name")
+	cmdInstall.Flag.BoolVar(&dedupeInstall, "dedupe", false, "deduplicate")
+	cmdInstall.Flag.StringVar(&fromInstall, "from", "", "installation source")
+	cmdInstall.Flag.StringVar(&osInstall, "os", "", "binary operating system")
+	cmdInstall.Flag.StringVar(&archInstall, "arch", "", "binary architecture")
+	cmdInstall.Flag.BoolVar(&signedInstall, "require-signature", false, "signature policy")
+	cmdInstall.Flag.IntVarP(&jobsInstall, "jobs", "j", 1, "concurrent downloads")
+	cmdInstall.Flag.IntVar(&compileInstall, "compile-jobs", 1, "concurrent compilations")
 	cmdInstall.register()
 }
 
 // fun the install command
-func runInstall(cmd *Command, args ...string) {
-	if len(args) == 0 {
-		cmd.DisplayUsageAndExit()
+func runInstall(cmd *Command, args ...string) error {
+	if len(args) == 0 && (repoInstall == "" || nameInstall == "") {
+		return ErrUsage
+	}
+	if (osInstall != "" || archInstall != "") && !binaryInstall {
+		return suggestError(
+			errors.New("--os and --arch can only be used installing binaries"),
+			"add the '-b' option")
 	}
 	options := func(i *Install) {
 		i.Verbose = verboseInstall
 		i.Force = forceInstall
 		i.NoCGO = nocgoInstall
 		i.BootStrap = bootStrap
+		i.Dedupe = dedupeInstall
+		i.From = fromInstall
+		i.OS = osInstall
+		i.Arch = archInstall
+		i.Signed = signedInstall
+		i.Log = cmd.Log
 		if binaryInstall {
 			i.Source = Binary
 		} else {
@@ -109,20 +208,46 @@ func runInstall(cmd *Command, args ...string) {
 				i.Source = Source
 			}
 		}
-		i.Version = args[0]
+		if repoInstall != "" {
+			i.Source = Repository
+			i.Repo = repoInstall
+			i.Ref = refInstall
+			i.Name = nameInstall
+		}
+		if len(args) > 0 {
+			i.Version = args[0]
+		}
+	}
+	var runner Runner = NewInstall(options)
+	if len(args) > 1 {
+		if repoInstall != "" {
+			return errors.New("only one version can be installed from a repository")
+		}
+		runner = NewInstallMany(func(m *InstallMany) {
+			m.Jobs = jobsInstall
+			m.CompileJobs = compileInstall
+			m.Dedupe = dedupeInstall
+			m.Log = cmd.Log
+			for _, version := range args {
+				m.Installs = append(m.Installs, NewInstall(options, func(i *Install) {
+					i.Version = version
+					i.Dedupe = false
+				}))
+			}
+		})
 	}
-	i := NewInstall(options)
-	data, err := i.Run()
+	data, err := runner.Run()
 	if err != nil {
-		fmt.Println(utils.Fail(fmt.Sprintf("error: %v", err)))
+		if len(args) > 1 {
+			fmt.Println(data)
+		}
 		if !verboseInstall {
-			fmt.Printf(
-				"%s: run the install command with the '-v' option\n", suggest)
+			return suggestError(err, "run the install command with the '-v' option")
 		}
-		os.Exit(2)
+		return err
 	}
 	fmt.Println(data)
-	os.Exit(0)
+	return nil
 }
 
 // Create a new install command and return back it's address
@@ -137,53 +262,236 @@ func NewInstall(options ...func(i *Install)) *Install {
 
 // implements the Runner interface executing the required installation
 func (i *Install) Run() (string, error) {
-	switch i.Source {
-	case Mercurial:
-		return i.fromGit()
-	case Source:
-		return i.fromSource()
-	case Binary:
-		return i.fromBinary()
-	default:
-		return "", errors.New("Install.Source is not a valid source")
+	source, request, err := i.resolve()
+	if err != nil {
+		if source == nil {
+			return "", err
+		}
+		return "error while installing from " + source.Describe(request), err
+	}
+	if err := source.Fetch(request); err != nil {
+		return "error while installing from " + source.Describe(request), err
+	}
+	if err := source.Build(request); err != nil {
+		return "error while compiling from " + source.Describe(request), err
 	}
+	result, err := i.finish(source, request)
+	if err != nil || !i.Dedupe {
+		return result, err
+	}
+	saved, err := cache.Dedupe(i.Log, request.Name)
+	if err != nil {
+		return "error while deduplicating", err
+	}
+	return fmt.Sprintf("%s (%s saved)", result, humanBytes(saved)), nil
 }
 
-// install from github source
-func (i *Install) fromGit() (string, error) {
-	if err := cache.CacheDownloadGit(i.Version, i.Force); err != nil {
-		return "error while installing from github", err
+// lookup the installation source and resolve the installation request
+func (i *Install) resolve() (cache.InstallSource, *cache.InstallRequest, error) {
+	source, err := i.installSource()
+	if err != nil {
+		return nil, nil, err
+	}
+	request := &cache.InstallRequest{
+		Version:   i.Version,
+		Name:      i.Name,
+		Force:     i.Force,
+		Verbose:   i.Verbose,
+		NoCGO:     i.NoCGO,
+		BootStrap: i.BootStrap,
+		Repo:      i.Repo,
+		Ref:       i.Ref,
+		GOOS:      i.OS,
+		GOARCH:    i.Arch,
+		Log:       i.Log,
 	}
-	if err := cache.Compile(i.Version, i.Verbose, i.NoCGO, i.BootStrap); err != nil {
-		return "error while compiling from github", err
+	if i.Signed {
+		request.Signature = cache.SignatureRequired
 	}
+	return source, request, source.Resolve(request)
+}
+
+// record the installation metadata and generate the result message
+func (i *Install) finish(
+	source cache.InstallSource, request *cache.InstallRequest) (string, error) {
 
-	result := fmt.Sprintf(
-		"%s", utils.Ok(fmt.Sprintf("Go %s installed", i.Version)))
+	metadata, err := cache.SaveInstallMetadata(source, request)
+	if err != nil {
+		return "error while writing installation metadata", err
+	}
+	if i.Signed && metadata.Signature == "" {
+		return "error while verifying signature", fmt.Errorf(
+			"Go %s has not been verified with a signature", request.Name)
+	}
+
+	result := utils.Ok(fmt.Sprintf("Go %s installed", i.Version))
+	if metadata.Commit != "" {
+		result = utils.Ok(fmt.Sprintf("Go %s installed from %s at %s",
+			request.Name, source.Describe(request), metadata.Commit))
+	}
+	if metadata.Signature != "" {
+		result = fmt.Sprintf("%s (signed by %s)", result, metadata.Signature)
+	}
 	return result, nil
 }
 
-// install from tar.gz source
-func (i *Install) fromSource() (string, error) {
-	if err := cache.CacheDownload(i.Version, i.Force); err != nil {
-		return "error while installing from tar.gz source", err
+// return back the installation source registered in the cache for the
+// install command, From takes precedence over Source
+func (i *Install) installSource() (cache.InstallSource, error) {
+	if i.From != "" {
+		return cache.LookupSource(i.From)
+	}
+	name, ok := sourceNames[i.Source]
+	if !ok {
+		return nil, errors.New("Install.Source is not a valid source")
+	}
+	return cache.LookupSource(name)
+}
+
+// install command for several versions at the same time
+type InstallMany struct {
+	Installs    []*Install
+	Jobs        int
+	CompileJobs int
+	Dedupe      bool
+	Log         *logger.Logger
+
+	mu       sync.Mutex
+	finished int
+}
+
+// result of one of the installations of an InstallMany command
+type installResult struct {
+	version string
+	result  string
+	err     error
+	name    string
+}
+
+// Create a new install many command and return back it's address
+func NewInstallMany(options ...func(m *InstallMany)) *InstallMany {
+	many := &InstallMany{Jobs: 1, CompileJobs: 1}
+	for _, option := range options {
+		option(many)
 	}
-	if err := cache.Compile(i.Version, i.Verbose, i.NoCGO, i.BootStrap); err != nil {
-		return "error while installing from tar.gz source", err
+	return many
+}
+
+// implements the Runner interface installing all the versions concurrently,
+// downloads are limited by Jobs and compilations by CompileJobs
+func (m *InstallMany) Run() (string, error) {
+	if m.Jobs < 1 {
+		m.Jobs = 1
+	}
+	if m.CompileJobs < 1 {
+		m.CompileJobs = 1
+	}
+	// the step by step output of each installation is replaced with the
+	// aggregated progress
+	for _, install := range m.Installs {
+		install.Log = nil
 	}
 
-	result := fmt.Sprintf(
-		"%s", utils.Ok(fmt.Sprintf("Go %s installed", i.Version)))
-	return result, nil
+	fetching := make(chan struct{}, m.Jobs)
+	compiling := make(chan struct{}, m.CompileJobs)
+	results := make([]*installResult, len(m.Installs))
+	var wg sync.WaitGroup
+	for n, install := range m.Installs {
+		wg.Add(1)
+		go func(n int, install *Install) {
+			defer wg.Done()
+			results[n] = m.install(install, fetching, compiling)
+			m.done(results[n])
+		}(n, install)
+	}
+	wg.Wait()
+
+	failed, names := 0, []string{}
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			continue
+		}
+		names = append(names, r.name)
+	}
+	if m.Dedupe && len(names) > 0 {
+		if _, err := cache.Dedupe(m.Log, names...); err != nil {
+			return m.table(results), err
+		}
+	}
+	if failed > 0 {
+		return m.table(results), fmt.Errorf(
+			"%d of %d installations failed", failed, len(results))
+	}
+	return m.table(results), nil
 }
 
-// install from binary source
-func (i *Install) fromBinary() (string, error) {
-	if err := cache.CacheDownloadBinary(i.Version, i.Force); err != nil {
-		return "error while installing from binary", err
+// run the installation steps of a single version
+func (m *InstallMany) install(
+	i *Install, fetching, compiling chan struct{}) *installResult {
+
+	r := &installResult{version: i.Version}
+	source, request, err := i.resolve()
+	if err != nil {
+		r.err = err
+		return r
 	}
+	r.name = request.Name
 
-	result := fmt.Sprintf(
-		"%s", utils.Ok(fmt.Sprintf("Go %s installed", i.Version)))
-	return result, nil
+	fetching <- struct{}{}
+	m.progress(i.Version, "downloading from %s", source.Describe(request))
+	err = source.Fetch(request)
+	<-fetching
+	if err != nil {
+		r.err = fmt.Errorf("error while installing from %s: %v", source.Describe(request), err)
+		return r
+	}
+
+	compiling <- struct{}{}
+	m.progress(i.Version, "compiling")
+	err = source.Build(request)
+	<-compiling
+	if err != nil {
+		r.err = fmt.Errorf("error while compiling from %s: %v", source.Describe(request), err)
+		return r
+	}
+	r.result, r.err = i.finish(source, request)
+	return r
+}
+
+// print the aggregated progress of the installations
+func (m *InstallMany) progress(version, format string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Log.Info(fmt.Sprintf("[%d/%d] %s %s", m.finished, len(m.Installs),
+		version, fmt.Sprintf(format, args...)),
+		"version", version, "finished", m.finished, "total", len(m.Installs))
+}
+
+// mark an installation as finished in the aggregated progress
+func (m *InstallMany) done(r *installResult) {
+	m.mu.Lock()
+	m.finished++
+	m.mu.Unlock()
+	if r.err != nil {
+		m.progress(r.version, utils.Fail("✖"))
+		return
+	}
+	m.progress(r.version, utils.Ok("✔"))
+}
+
+// generates the final table with the result of each installation
+func (m *InstallMany) table(results []*installResult) string {
+	buf := new(bytes.Buffer)
+	w := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Version\tResult")
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(w, "%s\t%s %v\n", r.version, utils.Fail("✖"), r.err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s %s\n", r.version, utils.Ok("✔"), r.result)
+	}
+	w.Flush()
+	return strings.TrimRight(buf.String(), "\n")
 }
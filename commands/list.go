@@ -23,18 +23,19 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/DamnWidget/VenGO/cache"
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/DamnWidget/VenGO/utils"
 )
 
 var cmdList = &Command{
 	Name:  "list",
-	Usage: "list [-a, --all] [-n, --non-installed] [-j, --json]",
+	Usage: "list [-a, --all] [-n, --non-installed] [-s, --by-source] [-j, --json]",
 	Short: "List installed and available Go versions",
 	Long: fmt.Sprintf(`
 Shows a list of installed Go versions, available non installed Go versions or
@@ -53,6 +54,11 @@ and source.tar.gz packed versions.
 The flag -a or --all is used to show all the available to install and installed
 Go versions.
 
+The flag -s or --by-source groups the installed Go versions by the source that
+was used to install them (git, tarball, binary, repository, adopted...), the
+source of versions installed with older VenGO releases is inferred from their
+names. In JSON output the groups are added as a "by_source" object.
+
 JSON output:
   One can pass the -j or --json option to display the output as a JSON
   structure with the following format:
@@ -74,6 +80,7 @@ var (
 	allList          bool
 	nonInstalledList bool
 	asJsonList       bool
+	bySourceList     bool
 )
 
 // initialize the command
@@ -81,18 +88,21 @@ func init() {
 	cmdList.Flag.BoolVarP(&allList, "all", "a", false, "")
 	cmdList.Flag.BoolVarP(&nonInstalledList, "non-installed", "n", false, "")
 	cmdList.Flag.BoolVarP(&asJsonList, "json", "j", false, "")
+	cmdList.Flag.BoolVarP(&bySourceList, "by-source", "s", false, "")
 	cmdList.register()
 }
 
 // run the list command
-func runList(cmd *Command, args ...string) {
+func runList(cmd *Command, args ...string) error {
 	options := func(l *List) {
 		l.DisplayAs = Text
 		if asJsonList {
 			l.DisplayAs = Json
 		}
 		l.ShowBoth = allList
+		l.GroupBySource = bySourceList
 		l.ShowInstalled = true
+		l.Log = cmd.Log
 		if nonInstalledList {
 			l.ShowNotInstalled = true
 			if !l.ShowBoth {
@@ -102,18 +112,19 @@ func runList(cmd *Command, args ...string) {
 	}
 	nl := NewList(options)
 	data, err := nl.Run()
-	if err == nil {
-		fmt.Println(data)
-		return
+	if err != nil {
+		return err
 	}
-	log.Println(err)
-	os.Exit(2)
+	fmt.Println(data)
+	return nil
 }
 
 // json brief output structure
 type BriefJSON struct {
-	Installed []string `json:"installed,omitempty"`
-	Available []string `json:"available,omitempty"`
+	Installed []string            `json:"installed,omitempty"`
+	Available []string            `json:"available,omitempty"`
+	BySource  map[string][]string `json:"by_source,omitempty"`
+	System    []string            `json:"system,omitempty"`
 }
 
 // list command
@@ -121,7 +132,9 @@ type List struct {
 	ShowInstalled    bool
 	ShowNotInstalled bool
 	ShowBoth         bool
+	GroupBySource    bool
 	DisplayAs        int
+	Log              *logger.Logger
 }
 
 // Create a new list and return back it's address
@@ -140,7 +153,10 @@ func NewList(options ...func(*List)) *List {
 // go versions, a list of not installed versions or all versions depending
 // on the list options
 func (l *List) Run() (string, error) {
-	tags := cache.Tags()
+	tags, err := cache.Tags(l.Log)
+	if err != nil {
+		return "", err
+	}
 	sources := cache.AvailableSources()
 	binaries := cache.AvailableBinaries()
 
@@ -150,7 +166,6 @@ func (l *List) Run() (string, error) {
 	}
 	installed, err := cache.GetInstalled(tags, sources, binaries)
 	if err != nil {
-		fmt.Println("while running List command:", err)
 		return "error while running the command", err
 	}
 	versions["installed"] = append(versions["installed"], installed...)
@@ -166,16 +181,19 @@ func (l *List) Run() (string, error) {
 func (l *List) display(versions map[string][]string) (string, error) {
 	output := []string{}
 	if l.DisplayAs == Text {
-		if l.ShowBoth || l.ShowInstalled {
+		if (l.ShowBoth || l.ShowInstalled) && l.GroupBySource {
+			groups := groupBySource(versions["installed"])
+			for _, source := range sortedKeys(groups) {
+				output = append(output, utils.Ok(
+					fmt.Sprintf("Installed from %s", source)))
+				for _, v := range groups[source] {
+					output = append(output, installedLine(v))
+				}
+			}
+		} else if l.ShowBoth || l.ShowInstalled {
 			output = append(output, utils.Ok("Installed"))
 			for _, v := range versions["installed"] {
-				_, err := os.Stat(
-					filepath.Join(cache.CacheDirectory(), v, ".vengo-manifest"))
-				check := utils.Ok("✔")
-				if err != nil {
-					check = utils.Fail("✖")
-				}
-				output = append(output, fmt.Sprintf("    %s %s", v, check))
+				output = append(output, installedLine(v))
 			}
 		}
 		if l.ShowBoth || l.ShowNotInstalled {
@@ -186,12 +204,20 @@ func (l *List) display(versions map[string][]string) (string, error) {
 	}
 
 	if l.DisplayAs == Json {
-		jsonData := &BriefJSON{[]string{}, []string{}}
+		jsonData := &BriefJSON{[]string{}, []string{}, nil, nil}
 		if l.ShowBoth || l.ShowInstalled {
 			for _, v := range versions["installed"] {
 				v := strings.TrimLeft(v, "    ")
 				jsonData.Installed = append(jsonData.Installed, v)
 			}
+			if l.GroupBySource {
+				jsonData.BySource = groupBySource(jsonData.Installed)
+			}
+			for _, v := range jsonData.Installed {
+				if cache.InSystemCache(v) {
+					jsonData.System = append(jsonData.System, v)
+				}
+			}
 		}
 		if l.ShowBoth || l.ShowNotInstalled {
 			for _, v := range versions["available"] {
@@ -205,3 +231,38 @@ func (l *List) display(versions map[string][]string) (string, error) {
 
 	return "", fmt.Errorf("List.DisplayAs is not set to a valid value!")
 }
+
+// generates the output line of an installed version with it's integrity mark
+func installedLine(v string) string {
+	_, err := os.Stat(filepath.Join(cache.VersionPath(v), ".vengo-manifest"))
+	check := utils.Ok("✔")
+	if cache.IsArchived(v) {
+		check = utils.Ok("(archived)")
+	} else if err != nil {
+		check = utils.Fail("✖")
+	}
+	if cache.InSystemCache(v) {
+		check = fmt.Sprintf("%s (system)", check)
+	}
+	return fmt.Sprintf("    %s %s", v, check)
+}
+
+// group the given installed versions by their installation source
+func groupBySource(installed []string) map[string][]string {
+	groups := map[string][]string{}
+	for _, v := range installed {
+		source := cache.InstalledSource(v)
+		groups[source] = append(groups[source], v)
+	}
+	return groups
+}
+
+// return back the sorted keys of a map of groups
+func sortedKeys(groups map[string][]string) []string {
+	keys := []string{}
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -29,12 +29,14 @@ import (
 	"strings"
 
 	"github.com/DamnWidget/VenGO/cache"
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/DamnWidget/VenGO/utils"
 )
 
 var cmdLsenvs = &Command{
 	Name:  "lsenvs",
-	Usage: "lsenvs [-j]",
+	Usage: "lsenvs [-j] [--projects]",
 	Short: "Lists available virtual Go environments",
 	Long: fmt.Sprintf(`Lists isolated virtual Go environments in your system. Integrity compromised
 environments are shown as the legend shown below:
@@ -45,18 +47,24 @@ environments are shown as the legend shown below:
    %s    if the integrity is compromised
 
 If the -j or --json option is passed, the command resturn a JSON string instead.
+
+Project environments created with 'vengo mkenv --here' show their project
+directory, use --projects to list only them.
 `, utils.Ok("✔"), utils.Fail("✖")),
 	Execute: runLsenvs,
 }
 
+var projectsList bool
+
 // initialize the command
 func init() {
 	cmdLsenvs.Flag.BoolVarP(&asJsonList, "json", "j", false, "display JSON")
+	cmdLsenvs.Flag.BoolVar(&projectsList, "projects", false, "only project environments")
 	cmdLsenvs.register()
 }
 
 // run the lsenvs command
-func runLsenvs(cmd *Command, args ...string) {
+func runLsenvs(cmd *Command, args ...string) error {
 	options := []func(el *EnvironmentsList){}
 
 	if asJsonList {
@@ -64,14 +72,21 @@ func runLsenvs(cmd *Command, args ...string) {
 			el.DisplayAs = Json
 		})
 	}
+	if projectsList {
+		options = append(options, func(el *EnvironmentsList) {
+			el.Projects = true
+		})
+	}
+	options = append(options, func(el *EnvironmentsList) {
+		el.Log = cmd.Log
+	})
 	nel := NewEnvironmentsList(options...)
 	data, err := nel.Run()
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(2)
+		return err
 	}
 	fmt.Println(data)
-	os.Exit(0)
+	return nil
 }
 
 type EnvironmentsJSON struct {
@@ -82,6 +97,8 @@ type EnvironmentsJSON struct {
 // EnvironmentsList command
 type EnvironmentsList struct {
 	DisplayAs int
+	Projects  bool
+	Log       *logger.Logger
 }
 
 // Create a new lsenv adn returns back it's address
@@ -100,7 +117,7 @@ func NewEnvironmentsList(options ...func(*EnvironmentsList)) *EnvironmentsList {
 func (e *EnvironmentsList) Run() (string, error) {
 	available, invalid, err := e.getEnvironments()
 	if err != nil {
-		fmt.Println("while running EnvironmentsList command:", err)
+		e.Log.Error(fmt.Sprintf("while running EnvironmentsList command: %v", err))
 		return "error while running the command", err
 	}
 
@@ -135,10 +152,10 @@ func (e *EnvironmentsList) display(
 
 // return a list of available virtual go environments for the user
 func (e *EnvironmentsList) getEnvironments() ([]string, []string, error) {
-	envs_path := filepath.Join("~", ".VenGO", "*")
-	files, err := filepath.Glob(cache.ExpandUser(envs_path))
+	envs_path := filepath.Join(cache.VenGO_PATH, "*")
+	files, err := filepath.Glob(envs_path)
 	if err != nil {
-		fmt.Println("while getting list of environments:", err)
+		e.Log.Error(fmt.Sprintf("while getting list of environments: %v", err))
 		return nil, nil, err
 	}
 	available, invalid := []string{}, []string{}
@@ -147,17 +164,20 @@ func (e *EnvironmentsList) getEnvironments() ([]string, []string, error) {
 		filename := path.Base(file)
 		stat, err := os.Stat(file)
 		if err != nil {
-			fmt.Println("while getting list of environments:", err)
+			e.Log.Error(fmt.Sprintf("while getting list of environments: %v", err))
 			return nil, nil, err
 		}
 		if stat.IsDir() && filename != "bin" && filename != "scripts" {
-			_, err := os.Open(filepath.Join(file, "bin", "activate"))
+			c, err := env.LoadConfig(file)
 			if err != nil {
 				if os.IsNotExist(err) || os.IsPermission(err) {
 					invalid = append(invalid, filename)
 				}
 				continue
 			}
+			if e.Projects && c.Project == "" {
+				continue
+			}
 			if r, err := os.Readlink(filepath.Join(file, "lib")); err != nil {
 				if os.IsNotExist(err) || os.IsPermission(err) {
 					invalid = append(invalid, filename)
@@ -166,6 +186,9 @@ func (e *EnvironmentsList) getEnvironments() ([]string, []string, error) {
 			} else {
 				if e.DisplayAs == Text {
 					vengoenv = fmt.Sprintf("%-22s%-8s", filename, path.Base(r))
+					if c.Project != "" {
+						vengoenv = fmt.Sprintf("%s  %s", vengoenv, c.Project)
+					}
 				} else {
 					vengoenv = filename
 				}
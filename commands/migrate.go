@@ -23,11 +23,9 @@ package commands
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/DamnWidget/VenGO/cache"
 	"github.com/DamnWidget/VenGO/env"
-	"github.com/DamnWidget/VenGO/utils"
 )
 
 var cmdMigrate = &Command{
@@ -44,57 +42,50 @@ func init() {
 }
 
 // run the migrate command
-func runMigrate(cmd *Command, args ...string) {
+func runMigrate(cmd *Command, args ...string) error {
 	if len(args) < 2 {
-		cmd.DisplayUsageAndExit()
+		return ErrUsage
 	}
 	environName := args[0]
 	goVersion := args[1]
 	if os.Getenv("VENGO_ENV") == environName {
-		fmt.Println(
-			"error:", fmt.Sprintf("%s is currently in use as the active environment"))
-		fmt.Printf("%s: execute 'deactivate' before call this command\n", suggest)
-		os.Exit(2)
+		return suggestError(fmt.Errorf(
+			"%s is currently in use as the active environment", environName),
+			"execute 'deactivate' before call this command")
 	}
-	envPath := filepath.Join(os.Getenv("VENGO_HOME"), environName)
-	if _, err := os.Stat(envPath); err != nil {
-		fmt.Printf("%s is no a VenGO environment...\n", environName)
-		fmt.Println(err)
-		os.Exit(2)
+	if _, err := env.LoadConfig(environName); err != nil {
+		return fmt.Errorf("%s is no a VenGO environment: %v", environName, err)
 	}
-	fmt.Print("Checking installed Go versions...")
-	if !env.LookupInstalledVersion(goVersion) {
-		fmt.Println(utils.Fail("✖"))
-		fmt.Println(fmt.Sprintf(
-			"sorry vengo can't perform the operation because %s is %s",
-			goVersion, utils.Fail("not installed")))
-		fmt.Printf("%s: run 'vengo install %s'\n", suggest, goVersion)
-		os.Exit(2)
+	step := cmd.Log.Step("Checking installed Go versions")
+	installed, err := env.LookupInstalledVersion(cmd.Log, goVersion)
+	if err != nil {
+		step.Fail(err)
+		return err
 	}
-	fmt.Println(utils.Ok("✔"))
-
-	library := filepath.Join(envPath, "lib")
-	installed, _ := os.Readlink(library)
-	if installed == goVersion {
-		fmt.Printf("%s in use Go version is already %s, skipping...", environName, installed)
-		os.Exit(0)
+	if !installed {
+		step.Fail(nil)
+		return suggestError(fmt.Errorf(
+			"sorry vengo can't perform the operation because %s is not installed",
+			goVersion), "run 'vengo install %s'", goVersion)
 	}
-	fmt.Printf("unlinking previous %s Go version from %s...", installed, environName)
-	if err := os.Remove(library); err != nil {
-		fmt.Println(utils.Fail("✖"))
-		fmt.Println(err)
-		os.Exit(2)
+	step.Ok()
+	if err := cache.Restore(cmd.Log, goVersion); err != nil {
+		return err
 	}
-	fmt.Println(utils.Ok("✔"))
-	fmt.Printf("Linking Go version %s...", goVersion)
-	path := filepath.Join(cache.CacheDirectory(), goVersion)
-	err := os.Symlink(path, filepath.Join(envPath, "lib"))
-	if err != nil {
-		fmt.Println(utils.Fail("✖"))
-		fmt.Println(err)
-		os.Exit(2)
+
+	linked, _ := env.LinkedVersion(environName)
+	if linked == goVersion {
+		cmd.Log.Info(fmt.Sprintf(
+			"%s in use Go version is already %s, skipping...", environName, linked))
+		return nil
+	}
+	step = cmd.Log.Step(
+		fmt.Sprintf("Linking Go version %s into %s", goVersion, environName))
+	if err := env.Relink(environName, goVersion); err != nil {
+		step.Fail(err)
+		return err
 	}
-	fmt.Println(utils.Ok("✔"))
-	fmt.Println("Done. You may want to run 'go build -a ./... in $GOPATH")
-	os.Exit(0)
+	step.Ok()
+	cmd.Log.Info("Done. You may want to run 'go build -a ./... in $GOPATH")
+	return nil
 }
@@ -24,15 +24,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/DamnWidget/VenGO/cache"
 	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
 	"github.com/DamnWidget/VenGO/utils"
 )
 
 var cmdMkenv = &Command{
 	Name:  "mkenv",
-	Usage: "mkenv [-f] [-p] -g env_name",
+	Usage: "mkenv [-f] [-p] [--own-caches] [--gomodcache dir] [--gocache dir] [--goflags flags] [--goproxy url] [--gonosumdb patterns] [--go111module mode] [--env KEY=VALUE] [--shells list] [--here] -g env_name",
 	Short: "Create a new Virtual Go Environment",
 	Long: `Creates a new Isolated Virtual Go Environments, the Go version to use must
 be specified as argument for the parameter -g or --go, if no version is passed,
@@ -46,6 +48,37 @@ can be specified passing a string to the parameter -p or --prompt for example:
 
 If the environment already exists, it can be regenerated using the -f or --force
 flag
+
+The Go modules settings of the environment can be configured with the flags
+--gomodcache, --gocache, --goflags, --goproxy, --gonosumdb and --go111module,
+they are exported when the environment is activated and restored to their
+previous values on deactivate. The --own-caches flag sets GOMODCACHE and
+GOCACHE to directories inside the environment so it doesn't share its module
+and build caches with other environments:
+
+    vengo mkenv --own-caches --goproxy https://proxy.golang.org -g go1.16 mods
+
+Any other environment variable can be set passing KEY=VALUE to the --env flag
+as many times as needed, they can be changed later using 'vengo setenv' and
+'vengo unsetenv'. Variables are kept when the environment is regenerated
+using the --force flag:
+
+    vengo mkenv --env CGO_CFLAGS=-I/opt/include --env API=http://localhost -g go1.4 api
+
+Activate scripts are generated for bash (bin/activate), zsh (bin/activate.zsh),
+fish (bin/activate.fish), csh and tcsh (bin/activate.csh), nushell
+(bin/activate.nu) and PowerShell (bin/activate.ps1). Pass a comma separated
+list of shells to the --shells flag to generate only some of them:
+
+    vengo mkenv --shells bash,fish -g go1.4 myenv
+
+The --here flag creates a project environment that uses the current directory
+as GOPATH instead of the environment directory. A .vengo file naming the
+environment and its Go version is written into the project so 'vengo activate'
+finds it when is called without arguments from the project or any of its
+subdirectories. The environment name defaults to the directory name:
+
+    cd ~/projects/api && vengo mkenv --here -g go1.16
 `,
 	Execute: runMkenv,
 }
@@ -54,6 +87,11 @@ var (
 	forceMkenv     bool
 	promptMkenv    string
 	goversionMkenv string
+	ownCacheMkenv  bool
+	modulesMkenv   = map[string]*string{}
+	envMkenv       = variablesFlag{}
+	shellsMkenv    string
+	hereMkenv      bool
 )
 
 // initialize the command
@@ -61,13 +99,31 @@ func init() {
 	cmdMkenv.Flag.BoolVarP(&forceMkenv, "force", "f", false, "force creation")
 	cmdMkenv.Flag.StringVarP(&promptMkenv, "prompt", "p", "", "prompt")
 	cmdMkenv.Flag.StringVarP(&goversionMkenv, "go", "g", "tip", "go version")
+	cmdMkenv.Flag.BoolVar(&ownCacheMkenv, "own-caches", false, "own module and build caches")
+	for _, name := range env.ModuleVariables {
+		modulesMkenv[name] = cmdMkenv.Flag.String(strings.ToLower(name), "", name)
+	}
+	cmdMkenv.Flag.Var(envMkenv, "env", "environment variable")
+	cmdMkenv.Flag.StringVar(&shellsMkenv, "shells", "", "shells")
+	cmdMkenv.Flag.BoolVar(&hereMkenv, "here", false, "project environment")
 	cmdMkenv.register()
 }
 
 // run the mkenv command
-func runMkenv(cmd *Command, args ...string) {
-	if len(args) == 0 {
-		cmd.DisplayUsageAndExit()
+func runMkenv(cmd *Command, args ...string) error {
+	if len(args) > 1 || len(args) == 0 && !hereMkenv {
+		return ErrUsage
+	}
+	name, project := "", ""
+	if hereMkenv {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		name, project = filepath.Base(cwd), cwd
+	}
+	if len(args) == 1 {
+		name = args[0]
 	}
 	options := func(m *Mkenv) {
 		m.Force = forceMkenv
@@ -77,30 +133,48 @@ func runMkenv(cmd *Command, args ...string) {
 		if promptMkenv != "" {
 			m.Prompt = promptMkenv
 		}
-		m.Name = args[0]
+		m.Name = name
+		m.Project = project
+		m.OwnCaches = ownCacheMkenv
+		m.Log = cmd.Log
+		m.Env = map[string]string{}
+		for name, value := range modulesMkenv {
+			if *value != "" {
+				m.Env[name] = *value
+			}
+		}
+		for name, value := range envMkenv {
+			m.Env[name] = value
+		}
+		if shellsMkenv != "" {
+			m.Shells = strings.Split(shellsMkenv, ",")
+		}
 	}
 	mkenv := NewMkenv(options)
 	data, err := mkenv.Run()
 	if err != nil {
 		if IsNotInstalledError(err) {
-			fmt.Println(fmt.Sprintf(
-				"sorry vengo can't perform the operation because %s is %s",
-				mkenv.Version, utils.Fail("not installed")))
-			fmt.Printf("%s: run 'vengo install %s'\n", suggest, mkenv.Version)
-			os.Exit(2)
+			return suggestError(fmt.Errorf(
+				"sorry vengo can't perform the operation because %s is not installed",
+				mkenv.Version), "run 'vengo install %s'", mkenv.Version)
 		}
-		fmt.Println(err)
-		os.Exit(2)
+		return err
 	}
 	fmt.Println(data)
+	return nil
 }
 
 // mkenv command
 type Mkenv struct {
-	Force   bool
-	Name    string
-	Prompt  string
-	Version string
+	Force     bool
+	Name      string
+	Prompt    string
+	Version   string
+	OwnCaches bool
+	Env       map[string]string
+	Shells    []string
+	Project   string
+	Log       *logger.Logger
 }
 
 // Create a new mkenv command and return back it's address
@@ -117,27 +191,70 @@ func NewMkenv(options ...func(i *Mkenv)) *Mkenv {
 
 // implements the Runner interface creating the new virtual environment
 func (m *Mkenv) Run() (string, error) {
-	fmt.Fprint(cache.Output, "Checking installed Go versions... ")
+	step := m.Log.Step("Checking installed Go versions")
 	if err := m.checkInstalled(); err != nil {
-		fmt.Fprintln(cache.Output, utils.Fail("✖"))
+		step.Fail(err)
 		return "", err
 	}
-	fmt.Fprintln(cache.Output, utils.Ok("✔"))
+	step.Ok()
 
 	newEnv := env.NewEnvironment(m.Name, m.Prompt)
-	if newEnv.Exists() && !m.Force {
-		suggest := fmt.Sprintf(
-			"  %s: use --force to force reinstallation", utils.Ok("suggestion"))
-		return "", fmt.Errorf("error: %s already exists\n%s", m.Name, suggest)
+	newEnv.GoVersion = m.Version
+	newEnv.Log = m.Log
+	if newEnv.Exists() {
+		if !m.Force {
+			suggest := fmt.Sprintf(
+				"  %s: use --force to force reinstallation", utils.Ok("suggestion"))
+			return "", fmt.Errorf("error: %s already exists\n%s", m.Name, suggest)
+		}
+		// keep the settings of the environment that is being regenerated
+		if previous, err := env.LoadEnvironment(m.Name); err == nil {
+			newEnv.Env = previous.Env
+			newEnv.Hooks = previous.Hooks
+			newEnv.Shells = previous.Shells
+			if previous.Project != "" {
+				newEnv.SetProject(previous.Project)
+			}
+		}
+	}
+	if m.Project != "" {
+		if err := newEnv.SetProject(m.Project); err != nil {
+			return "", err
+		}
+	}
+	if len(m.Shells) > 0 {
+		if err := newEnv.SetShells(m.Shells); err != nil {
+			return "", err
+		}
+	}
+	if m.OwnCaches {
+		newEnv.SetVariable("GOMODCACHE", filepath.Join(newEnv.VenGO_PATH, "pkg", "mod"))
+		newEnv.SetVariable("GOCACHE", filepath.Join(newEnv.VenGO_PATH, ".cache", "go-build"))
+	}
+	for name, value := range m.Env {
+		if err := newEnv.SetVariable(name, value); err != nil {
+			return "", err
+		}
 	}
 	if err := newEnv.Generate(); err != nil {
-		os.RemoveAll(filepath.Join(os.Getenv("VENGO_HOME"), m.Name))
+		os.RemoveAll(filepath.Join(cache.VenGO_PATH, m.Name))
 		return "", err
 	}
 	if err := newEnv.Install(m.Version); err != nil {
-		os.RemoveAll(filepath.Join(os.Getenv("VENGO_HOME"), m.Name))
+		os.RemoveAll(filepath.Join(cache.VenGO_PATH, m.Name))
 		return "", err
 	}
+	if linked, err := env.LinkedVersion(m.Name); err == nil {
+		if platform, ok := cache.RunsOnHost(linked); !ok {
+			m.Log.Warn(fmt.Sprintf("%s is built for %s and can't run on this %s host",
+				linked, platform, cache.HostPlatform()))
+		}
+	}
+	if newEnv.Project != "" {
+		if err := newEnv.WriteMarker(); err != nil {
+			return "", err
+		}
+	}
 
 	return fmt.Sprintf(
 		"%s", utils.Ok(fmt.Sprintf(
@@ -147,7 +264,11 @@ func (m *Mkenv) Run() (string, error) {
 // check if the Go version used to generate the virtual environment is
 // installed or not, if is not, return a NotIntalled error type
 func (m *Mkenv) checkInstalled() error {
-	if !env.LookupInstalledVersion(m.Version) {
+	installed, err := env.LookupInstalledVersion(m.Log, m.Version)
+	if err != nil {
+		return err
+	}
+	if !installed {
 		return ErrNotInstalled
 	}
 	return nil
@@ -0,0 +1,154 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DamnWidget/VenGO/cache"
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
+	"github.com/DamnWidget/VenGO/utils"
+)
+
+var cmdRelocate = &Command{
+	Name:  "relocate",
+	Usage: "relocate [--cache dir] [--home dir]",
+	Short: "Move the VenGO cache and home to new locations",
+	Long: `Moves the Go versions cache, the VenGO home where the environments live or
+both into new directories. The lib symlinks, activate scripts and manifests
+of every environment are rewritten to point to the new locations.
+
+VenGO honors the VENGO_CACHE and VENGO_HOME environment variables to find the
+cache and the home, after relocating them make sure they are exported in your
+shell configuration.
+
+    vengo relocate --cache /data/vengo/cache --home /data/vengo/home
+`,
+	Execute: runRelocate,
+}
+
+var (
+	cacheRelocate string
+	homeRelocate  string
+)
+
+// initialize the command
+func init() {
+	cmdRelocate.Flag.StringVar(&cacheRelocate, "cache", "", "new cache directory")
+	cmdRelocate.Flag.StringVar(&homeRelocate, "home", "", "new home directory")
+	cmdRelocate.register()
+}
+
+// run the relocate command
+func runRelocate(cmd *Command, args ...string) error {
+	if cacheRelocate == "" && homeRelocate == "" {
+		return ErrUsage
+	}
+	relocate := NewRelocate(func(r *Relocate) {
+		r.Cache = cacheRelocate
+		r.Home = homeRelocate
+		r.Log = cmd.Log
+	})
+	data, err := relocate.Run()
+	if err != nil {
+		return err
+	}
+	fmt.Println(data)
+	return nil
+}
+
+// relocate command
+type Relocate struct {
+	Cache string
+	Home  string
+	Log   *logger.Logger
+}
+
+// create a new relocate command and return back it's address
+func NewRelocate(options ...func(r *Relocate)) *Relocate {
+	relocate := new(Relocate)
+	for _, option := range options {
+		option(relocate)
+	}
+	return relocate
+}
+
+// implements the Runner interface moving the cache and the home and
+// rewriting the environments to use the new locations
+func (r *Relocate) Run() (string, error) {
+	if os.Getenv("VENGO_ENV") != "" {
+		return "", errors.New(
+			"environments can't be relocated while one of them is active")
+	}
+	pairs := []string{}
+	exports := []string{}
+	if r.Cache != "" {
+		destination, err := filepath.Abs(r.Cache)
+		if err != nil {
+			return "", err
+		}
+		source := cache.CacheDirectory()
+		if err := cache.Relocate(r.Log, destination); err != nil {
+			return "", err
+		}
+		os.Setenv("VENGO_CACHE", destination)
+		pairs = append(pairs, source, destination)
+		exports = append(exports, fmt.Sprintf("VENGO_CACHE=%s", destination))
+	}
+	if r.Home != "" {
+		destination, err := filepath.Abs(r.Home)
+		if err != nil {
+			return "", err
+		}
+		step := r.Log.Step(fmt.Sprintf("Moving home to %s", destination))
+		if err := cache.MoveDirectory(cache.VenGO_PATH, destination); err != nil {
+			step.Fail(err)
+			return "", err
+		}
+		step.Ok()
+		pairs = append(pairs, cache.VenGO_PATH, destination)
+		cache.VenGO_PATH = destination
+		os.Setenv("VENGO_HOME", destination)
+		exports = append(exports, fmt.Sprintf("VENGO_HOME=%s", destination))
+	}
+
+	environments, err := env.Environments()
+	if err != nil {
+		return "", err
+	}
+	replacer := cache.NewPathReplacer(pairs...)
+	for _, name := range environments {
+		step := r.Log.Step(fmt.Sprintf("Rewriting %s", name))
+		if err := env.RewritePaths(name, replacer); err != nil {
+			step.Fail(err)
+			return "", err
+		}
+		step.Ok()
+	}
+
+	return fmt.Sprintf("%s\n%s: export %s in your shell configuration",
+		utils.Ok("Done"), suggest, strings.Join(exports, " ")), nil
+}
@@ -22,11 +22,10 @@ package commands
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 
-	"github.com/DamnWidget/VenGO/utils"
+	"github.com/DamnWidget/VenGO/cache"
 )
 
 var cmdRmenv = &Command{
@@ -45,26 +44,23 @@ func init() {
 }
 
 // run the rmenv command
-func runRmenv(cmd *Command, args ...string) {
+func runRmenv(cmd *Command, args ...string) error {
 	if len(args) == 0 {
-		cmd.DisplayUsageAndExit()
+		return ErrUsage
 	}
 	env := args[0]
 	if os.Getenv("VENGO_ENV") == env {
-		fmt.Println(
-			"error:", fmt.Sprintf("%s is currently in use as the active environment"))
-		fmt.Printf("%s: execute 'deactivate' before call this command\n", suggest)
-		os.Exit(2)
+		return suggestError(
+			fmt.Errorf("%s is currently in use as the active environment", env),
+			"execute 'deactivate' before call this command")
 	}
-	envPath := filepath.Join(os.Getenv("VENGO_HOME"), env)
+	envPath := filepath.Join(cache.VenGO_PATH, env)
 	if _, err := os.Stat(envPath); err != nil {
-		fmt.Printf("%s is not a VenGO environment...\n", env)
-		fmt.Println(err)
-		os.Exit(2)
+		return fmt.Errorf("%s is not a VenGO environment: %v", env, err)
 	}
-	err := os.RemoveAll(envPath)
-	if err != nil {
-		log.Fatal(err)
+	if err := os.RemoveAll(envPath); err != nil {
+		return err
 	}
-	fmt.Printf("%s has been removed\n", utils.Ok(env))
+	cmd.Log.Info(fmt.Sprintf("%s has been removed", env))
+	return nil
 }
@@ -0,0 +1,175 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/utils"
+)
+
+var cmdSetenv = &Command{
+	Name:  "setenv",
+	Usage: "setenv env_name KEY=VALUE...",
+	Short: "Set environment variables in a Virtual Go Environment",
+	Long: `Sets the given environment variables in a VenGO environment, the variables
+are stored in the environment configuration and exported by the activate
+scripts every time that the environment is activated, their previous values
+are restored on deactivate. For example:
+
+    vengo setenv myenv CGO_CFLAGS=-I/opt/include API_URL=http://localhost:8080
+
+VenGO managed variables like GOROOT, GOPATH or PATH can't be set. If the
+environment is active, the changes are applied the next time it's activated.
+`,
+	Execute: runSetenv,
+}
+
+var cmdUnsetenv = &Command{
+	Name:  "unsetenv",
+	Usage: "unsetenv env_name KEY...",
+	Short: "Unset environment variables in a Virtual Go Environment",
+	Long: `Removes the given environment variables from a VenGO environment, they are
+not exported by the activate scripts anymore. For example:
+
+    vengo unsetenv myenv CGO_CFLAGS
+`,
+	Execute: runUnsetenv,
+}
+
+// initialize the commands
+func init() {
+	cmdSetenv.register()
+	cmdUnsetenv.register()
+}
+
+// run the setenv command
+func runSetenv(cmd *Command, args ...string) error {
+	if len(args) < 2 {
+		return ErrUsage
+	}
+	variables := variablesFlag{}
+	for _, arg := range args[1:] {
+		if err := variables.Set(arg); err != nil {
+			return err
+		}
+	}
+	setenv := NewSetenv(func(s *Setenv) {
+		s.Environment = args[0]
+		s.Set = variables
+	})
+	data, err := setenv.Run()
+	if err != nil {
+		return err
+	}
+	fmt.Println(data)
+	return nil
+}
+
+// run the unsetenv command
+func runUnsetenv(cmd *Command, args ...string) error {
+	if len(args) < 2 {
+		return ErrUsage
+	}
+	setenv := NewSetenv(func(s *Setenv) {
+		s.Environment = args[0]
+		s.Unset = args[1:]
+	})
+	data, err := setenv.Run()
+	if err != nil {
+		return err
+	}
+	fmt.Println(data)
+	return nil
+}
+
+// setenv command, used by both setenv and unsetenv
+type Setenv struct {
+	Environment string
+	Set         map[string]string
+	Unset       []string
+}
+
+// Create a new setenv command and return back it's address
+func NewSetenv(options ...func(s *Setenv)) *Setenv {
+	setenv := new(Setenv)
+	for _, option := range options {
+		option(setenv)
+	}
+	return setenv
+}
+
+// implements the Runner interface updating the environment variables and
+// generating the activate scripts again
+func (s *Setenv) Run() (string, error) {
+	environment, err := env.LoadEnvironment(s.Environment)
+	if err != nil {
+		return "", fmt.Errorf(
+			"%s is not a VenGO environment: %v", s.Environment, err)
+	}
+	for name, value := range s.Set {
+		if err := environment.SetVariable(name, value); err != nil {
+			return "", err
+		}
+	}
+	for _, name := range s.Unset {
+		if err := environment.UnsetVariable(name); err != nil {
+			return "", err
+		}
+	}
+	if err := environment.Generate(); err != nil {
+		return "", err
+	}
+	output := []string{
+		utils.Ok(fmt.Sprintf("%s environment updated", environment.Name))}
+	if os.Getenv("VENGO_ENV") == environment.VenGO_PATH {
+		output = append(output,
+			"the changes are applied the next time the environment is activated")
+	}
+	return strings.Join(output, "\n"), nil
+}
+
+// KEY=VALUE environment variables flag, it can be passed many times
+type variablesFlag map[string]string
+
+// implements the flag.Value interface
+func (v variablesFlag) String() string {
+	variables := []string{}
+	for name, value := range v {
+		variables = append(variables, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(variables)
+	return strings.Join(variables, " ")
+}
+
+// implements the flag.Value interface parsing a KEY=VALUE pair
+func (v variablesFlag) Set(variable string) error {
+	pair := strings.SplitN(variable, "=", 2)
+	if len(pair) != 2 || pair[0] == "" {
+		return fmt.Errorf("%s is not a KEY=VALUE environment variable", variable)
+	}
+	v[pair[0]] = pair[1]
+	return nil
+}
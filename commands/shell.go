@@ -0,0 +1,206 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+var cmdShell = &Command{
+	Name:  "shell",
+	Usage: "shell [--shell name] env_name",
+	Short: "Start a new shell with the environment activated",
+	Long: `Starts $SHELL as a child process with the given environment activated, the
+prompt, PATH, custom variables and hooks are applied as 'vengo activate' does.
+Exit the shell to go back to the parent shell as it was before, there is no
+need to call deactivate. bash, zsh and fish are supported, use the --shell
+flag to start other shell than the one in $SHELL, for example:
+
+    vengo shell myenv
+    vengo shell --shell fish myenv
+
+The user's shell configuration is loaded before the environment is activated.
+`,
+	Execute: runShell,
+}
+
+var shellName string
+
+// initialize the command
+func init() {
+	cmdShell.Flag.StringVar(&shellName, "shell", "", "shell")
+	cmdShell.register()
+}
+
+// run the shell command
+func runShell(cmd *Command, args ...string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+	s := NewShell(func(s *Shell) {
+		s.Environment = args[0]
+		s.Log = cmd.Log
+		if shellName != "" {
+			s.Shell, s.Path = shellName, ""
+		}
+	})
+	_, err := s.Run()
+	return err
+}
+
+// bash rcfile, it loads the user's bashrc and activates the environment
+const bashShellRc = `if [ -f ~/.bashrc ]; then
+    source ~/.bashrc
+fi
+source "$_VENGO_ACTIVATE"
+unset _VENGO_ACTIVATE
+`
+
+// zsh reads its startup files from ZDOTDIR, the user's zshenv is loaded from
+// the original ZDOTDIR and the zshrc activates the environment
+const (
+	zshShellEnv = `_vengo_shell_dir="$ZDOTDIR"
+ZDOTDIR="$_VENGO_ZDOTDIR"
+if [[ -f "$ZDOTDIR/.zshenv" ]]; then
+    source "$ZDOTDIR/.zshenv"
+fi
+_VENGO_ZDOTDIR="$ZDOTDIR"
+ZDOTDIR="$_vengo_shell_dir"
+unset _vengo_shell_dir
+`
+	zshShellRc = `ZDOTDIR="$_VENGO_ZDOTDIR"
+unset _VENGO_ZDOTDIR
+if [[ -f "$ZDOTDIR/.zshrc" ]]; then
+    source "$ZDOTDIR/.zshrc"
+fi
+source "$_VENGO_ACTIVATE"
+unset _VENGO_ACTIVATE
+`
+)
+
+// fish runs the init command after its configuration files
+const fishShellInit = `source "$_VENGO_ACTIVATE"; set -e _VENGO_ACTIVATE`
+
+// shell command
+type Shell struct {
+	Environment string
+	Shell       string
+	Path        string
+	Log         *logger.Logger
+}
+
+// Create a new shell command and return back it's address, the shell is
+// taken from $SHELL if no one is given
+func NewShell(options ...func(s *Shell)) *Shell {
+	s := new(Shell)
+	if path := os.Getenv("SHELL"); path != "" {
+		s.Shell, s.Path = filepath.Base(path), path
+	}
+	for _, option := range options {
+		option(s)
+	}
+	if s.Shell == "" {
+		s.Shell = "bash"
+	}
+	return s
+}
+
+// implements the Runner interface starting the shell with the environment
+// activated, if the shell exits with an error an *ExitError is returned
+func (s *Shell) Run() (string, error) {
+	if active := os.Getenv("VENGO_SHELL"); active != "" {
+		return "", suggestError(
+			fmt.Errorf("already running a shell of the %s environment", active),
+			"exit it before starting a new one")
+	}
+	environment, err := loadRestoredEnvironment(s.Environment, s.Log)
+	if err != nil {
+		return "", err
+	}
+
+	path := s.Path
+	if path == "" {
+		if path, err = exec.LookPath(s.Shell); err != nil {
+			return "", err
+		}
+	}
+	dir, err := ioutil.TempDir("", "vengo-shell")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	command, err := s.command(path, dir)
+	if err != nil {
+		return "", err
+	}
+	script, err := environment.ActivateScript(s.Shell)
+	if err != nil {
+		return "", err
+	}
+	command.Env = append(command.Env,
+		"VENGO_SHELL="+environment.Name, "_VENGO_ACTIVATE="+script)
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return "", exitError(command.Run())
+}
+
+// build the command that starts the shell with the environment activated,
+// the startup files are written into the given directory
+func (s *Shell) command(path, dir string) (*exec.Cmd, error) {
+	var command *exec.Cmd
+	switch s.Shell {
+	case "bash":
+		rc := filepath.Join(dir, "bashrc")
+		if err := ioutil.WriteFile(rc, []byte(bashShellRc), 0644); err != nil {
+			return nil, err
+		}
+		command = exec.Command(path, "--rcfile", rc, "-i")
+		command.Env = os.Environ()
+	case "zsh":
+		files := map[string]string{".zshenv": zshShellEnv, ".zshrc": zshShellRc}
+		for name, data := range files {
+			err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+			if err != nil {
+				return nil, err
+			}
+		}
+		zdotdir := os.Getenv("ZDOTDIR")
+		if zdotdir == "" {
+			zdotdir = os.Getenv("HOME")
+		}
+		command = exec.Command(path, "-i")
+		command.Env = append(os.Environ(),
+			"ZDOTDIR="+dir, "_VENGO_ZDOTDIR="+zdotdir)
+	case "fish":
+		command = exec.Command(path, "--init-command", fishShellInit)
+		command.Env = os.Environ()
+	default:
+		return nil, suggestError(
+			fmt.Errorf("%s is not supported by the shell command", s.Shell),
+			"use bash, zsh or fish with the --shell flag")
+	}
+	return command, nil
+}
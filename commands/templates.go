@@ -0,0 +1,126 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/utils"
+)
+
+var cmdTemplates = &Command{
+	Name:  "templates",
+	Usage: "templates dump [-f] [directory]",
+	Short: "Dump the activate scripts templates to customize them",
+	Long: `The templates used to generate the environments activate scripts are built
+into the vengo binary. Templates found in the templates directory of the VenGO
+home (~/.VenGO/templates by default) are used instead of the built in ones.
+
+  dump      Writes the built in templates into the templates directory, or
+            into the given directory, so they can be customized. Existing
+            templates are not overwritten unless -f or --force is passed.
+
+Customized templates are used the next time that the activate scripts of an
+environment are generated by 'vengo mkenv', 'vengo setenv' or 'vengo hook'.
+`,
+	Execute: runTemplates,
+}
+
+var forceTemplates bool
+
+// initialize the command
+func init() {
+	cmdTemplates.Flag.BoolVarP(&forceTemplates, "force", "f", false, "overwrite")
+	cmdTemplates.register()
+}
+
+// run the templates command
+func runTemplates(cmd *Command, args ...string) error {
+	if len(args) == 0 || args[0] != "dump" || len(args) > 2 {
+		return ErrUsage
+	}
+	templates := NewTemplates(func(t *Templates) {
+		t.Force = forceTemplates
+		if len(args) == 2 {
+			t.Directory = args[1]
+		}
+	})
+	data, err := templates.Run()
+	if err != nil {
+		return err
+	}
+	fmt.Println(data)
+	return nil
+}
+
+// templates dump command
+type Templates struct {
+	Directory string
+	Force     bool
+}
+
+// Create a new templates command and return back it's address
+func NewTemplates(options ...func(t *Templates)) *Templates {
+	templates := new(Templates)
+	for _, option := range options {
+		option(templates)
+	}
+	if templates.Directory == "" {
+		templates.Directory = env.TemplatesDirectory()
+	}
+	return templates
+}
+
+// implements the Runner interface writing the built in templates
+func (t *Templates) Run() (string, error) {
+	if err := os.MkdirAll(t.Directory, 0755); err != nil {
+		return "", err
+	}
+	written, skipped := []string{}, []string{}
+	for _, name := range env.Templates() {
+		filename := filepath.Join(t.Directory, name)
+		if _, err := os.Stat(filename); err == nil && !t.Force {
+			skipped = append(skipped, name)
+			continue
+		}
+		data, err := env.DefaultTemplate(name)
+		if err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+			return "", err
+		}
+		written = append(written, name)
+	}
+	output := []string{utils.Ok(fmt.Sprintf(
+		"%d templates written into %s", len(written), t.Directory))}
+	if len(skipped) > 0 {
+		output = append(output, fmt.Sprintf(
+			"%s already exist, use --force to overwrite them",
+			strings.Join(skipped, ", ")))
+	}
+	return strings.Join(output, "\n"), nil
+}
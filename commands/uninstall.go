@@ -22,73 +22,166 @@ package commands
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/DamnWidget/VenGO/cache"
-	"github.com/DamnWidget/VenGO/utils"
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
 )
 
 var cmdUninstall = &Command{
 	Name:  "uninstall",
-	Usage: "uninstall version",
+	Usage: "uninstall [-f] [-m version] version",
 	Short: "Uninstall an installed Go version",
-	Long: `Uninstalls a Go installed version, it doesn't remove any virtual Go
-environment that has been created using the deleted version but it will be
-shown as compromised by the 'lsenvs' command. Remember that environments can
-be migrated to other Go versions using the 'vengo migrate' command.
+	Long: `Uninstalls a Go installed version. The uninstall is refused if there are
+virtual Go environments using the version unless one of the following flags
+is passed:
+
+  -f, --force               uninstall anyway, the environments will be shown
+                            as compromised by the 'lsenvs' command
+  -m, --migrate-to version  migrate the environments to the given Go version
+                            before the version is uninstalled
+
+Environments can be also migrated to other Go versions one by one using the
+'vengo migrate' command.
 `,
 	Execute: runUninstall,
 }
 
+var (
+	forceUninstall     bool
+	migrateToUninstall string
+)
+
 // initialize command
 func init() {
+	cmdUninstall.Flag.BoolVarP(&forceUninstall, "force", "f", false, "")
+	cmdUninstall.Flag.StringVarP(&migrateToUninstall, "migrate-to", "m", "", "")
 	cmdUninstall.register()
 }
 
 // run the uninstall command
-func runUninstall(cmd *Command, args ...string) {
+func runUninstall(cmd *Command, args ...string) error {
 	if len(args) == 0 {
-		cmd.DisplayUsageAndExit()
+		return ErrUsage
+	}
+	u := NewUninstall(func(u *Uninstall) {
+		u.Version = args[0]
+		u.Force = forceUninstall
+		u.MigrateTo = migrateToUninstall
+		u.Log = cmd.Log
+	})
+	out, err := u.Run()
+	if err != nil {
+		return err
+	}
+	cmd.Log.Info(out)
+	return nil
+}
+
+// uninstall command
+type Uninstall struct {
+	Version   string
+	Force     bool
+	MigrateTo string
+	Log       *logger.Logger
+}
+
+// create a new uninstall command and return back it's address
+func NewUninstall(options ...func(u *Uninstall)) *Uninstall {
+	u := new(Uninstall)
+	for _, option := range options {
+		option(u)
 	}
-	version := args[0]
+	return u
+}
+
+// implements the Runner interface uninstalling the Go version, the
+// environments that use it are migrated first if MigrateTo is set
+func (u *Uninstall) Run() (string, error) {
 	activeEnv := os.Getenv("VENGO_ENV")
 	if activeEnv != "" {
-		if err := checkEnvironment(version, activeEnv); err != nil {
-			fmt.Println("error:", err)
-			fmt.Printf("%s: execute 'deactivate' before call this command\n", suggest)
-			os.Exit(2)
+		if err := checkEnvironment(u.Version, activeEnv); err != nil {
+			return "", suggestError(err, "execute 'deactivate' before call this command")
 		}
 	}
 
-	versionPath := filepath.Join(cache.CacheDirectory(), version)
+	versionPath := cache.VersionPath(u.Version)
 	if _, err := os.Stat(versionPath); err != nil {
-		log.Println(err)
 		if os.IsNotExist(err) {
-			fmt.Printf("%s is not a Go installed version...\n", version)
-			fmt.Printf("%s: try with 'vengo list'\n", suggest)
-			os.Exit(2)
+			return "", suggestError(
+				fmt.Errorf("%s is not a Go installed version", u.Version),
+				"try with 'vengo list'")
 		}
-		log.Fatal(err)
+		return "", err
 	}
-	err := os.RemoveAll(versionPath)
-	if err == nil {
-		fmt.Printf("%s has been uninstalled\n", utils.Ok(version))
+	if cache.InSystemCache(u.Version) {
+		return "", fmt.Errorf("%s can't be uninstalled from %s: %w",
+			u.Version, cache.SystemCacheDirectory(), cache.ErrSystemCache)
 	}
-	log.Fatal(err)
+
+	dependents, err := env.Dependents(u.Version)
+	if err != nil {
+		return "", err
+	}
+	if len(dependents) > 0 {
+		if err := u.migrateDependents(dependents); err != nil {
+			return "", err
+		}
+	}
+
+	os.Remove(filepath.Join(cache.ArchivesDirectory(), u.Version+".tar.gz"))
+	if err := os.RemoveAll(versionPath); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s has been uninstalled", u.Version), nil
+}
+
+// migrate the environments that depend on the version that is going to be
+// uninstalled, fails if neither Force nor MigrateTo has been set
+func (u *Uninstall) migrateDependents(dependents []string) error {
+	u.Log.Warn(fmt.Sprintf("%s is used by the following environments: %s",
+		u.Version, strings.Join(dependents, ", ")))
+	if u.MigrateTo == "" {
+		if u.Force {
+			return nil
+		}
+		return suggestError(fmt.Errorf("%s is in use", u.Version),
+			"use --migrate-to version to migrate them or --force to uninstall anyway")
+	}
+	if u.MigrateTo == u.Version {
+		return fmt.Errorf("can't migrate environments to %s itself", u.Version)
+	}
+	if _, err := os.Stat(cache.VersionPath(u.MigrateTo)); err != nil {
+		return fmt.Errorf("%s is not a Go installed version", u.MigrateTo)
+	}
+	if err := cache.Restore(u.Log, u.MigrateTo); err != nil {
+		return err
+	}
+	for _, name := range dependents {
+		step := u.Log.Step(fmt.Sprintf("Migrating %s to %s", name, u.MigrateTo))
+		if err := env.Relink(name, u.MigrateTo); err != nil {
+			step.Fail(err)
+			return err
+		}
+		step.Ok()
+	}
+	return nil
 }
 
 // checks if the in use environment has relation with a specific Go version
-func checkEnvironment(version, env string) error {
-	versionLink, err := os.Readlink(filepath.Join(env, "lib"))
+func checkEnvironment(version, envPath string) error {
+	versionLink, err := os.Readlink(filepath.Join(envPath, "lib"))
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	if path.Base(versionLink) == version {
 		return fmt.Errorf(
-			"%s is currently in use by the active environment", version, path.Base(env))
+			"%s is currently in use by the active environment %s",
+			version, path.Base(envPath))
 	}
 	return nil
 }
@@ -0,0 +1,314 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/DamnWidget/VenGO/cache"
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
+	"github.com/DamnWidget/VenGO/utils"
+	"github.com/mcuadros/go-version"
+)
+
+var cmdUpgrade = &Command{
+	Name:  "upgrade",
+	Usage: "upgrade [--minor] [--dry-run] [env...]",
+	Short: "Upgrade environments to the latest Go release",
+	Long: `Upgrades the given environments (all of them if none is given) to the newest
+patch release of the Go version that they are using, e.g. an environment that
+uses go1.4 is upgraded to go1.4.2. If the --minor flag is passed, the newest
+minor release is used instead (e.g. go1.3.3 to go1.4.2).
+
+The new Go version is installed from the same source that was used to install
+the version currently in use (git, tarball or binary), environments using Go
+versions installed from repositories or adopted are skipped. After the
+installation the environments are relinked like 'vengo migrate' does and a
+summary is shown.
+
+Use the --dry-run flag to show the upgrade plan without install or relink
+anything.
+`,
+	Execute: runUpgrade,
+}
+
+var (
+	minorUpgrade  bool
+	dryRunUpgrade bool
+)
+
+// initialize the command
+func init() {
+	cmdUpgrade.Flag.BoolVar(&minorUpgrade, "minor", false, "")
+	cmdUpgrade.Flag.BoolVar(&dryRunUpgrade, "dry-run", false, "")
+	cmdUpgrade.register()
+}
+
+// run the upgrade command
+func runUpgrade(cmd *Command, args ...string) error {
+	upgrade := NewUpgrade(func(u *Upgrade) {
+		u.Environments = args
+		u.Minor = minorUpgrade
+		u.DryRun = dryRunUpgrade
+		u.Log = cmd.Log
+	})
+	data, err := upgrade.Run()
+	if data != "" {
+		fmt.Println(data)
+	}
+	return err
+}
+
+// upgrade command
+type Upgrade struct {
+	Environments []string
+	Minor        bool
+	DryRun       bool
+	Log          *logger.Logger
+}
+
+// upgrade plan for a single environment
+type upgradeStep struct {
+	Environment string
+	From        string
+	To          string
+	Source      string
+	Status      string
+	platform    string
+	err         error
+}
+
+// Create a new upgrade command and return back it's address
+func NewUpgrade(options ...func(u *Upgrade)) *Upgrade {
+	upgrade := new(Upgrade)
+	for _, option := range options {
+		option(upgrade)
+	}
+	return upgrade
+}
+
+// implements the Runner interface upgrading the environments
+func (u *Upgrade) Run() (string, error) {
+	environments := u.Environments
+	if len(environments) == 0 {
+		var err error
+		if environments, err = env.Environments(); err != nil {
+			return "", err
+		}
+	}
+	steps := []*upgradeStep{}
+	for _, name := range environments {
+		steps = append(steps, u.plan(name))
+	}
+	if u.DryRun {
+		return u.summary(steps), nil
+	}
+
+	failed := 0
+	for _, step := range steps {
+		if step.Status != "pending" {
+			continue
+		}
+		if err := u.upgrade(step); err != nil {
+			step.Status = utils.Fail("failed")
+			step.err = err
+			failed++
+			continue
+		}
+		step.Status = utils.Ok("upgraded")
+	}
+	if failed > 0 {
+		return u.summary(steps), fmt.Errorf(
+			"%d of %d upgrades failed", failed, len(steps))
+	}
+	return u.summary(steps), nil
+}
+
+// generates the upgrade plan for the given environment
+func (u *Upgrade) plan(name string) *upgradeStep {
+	step := &upgradeStep{Environment: name, Status: "pending"}
+	ver, err := env.LinkedVersion(name)
+	if err != nil {
+		step.Status = "skipped (not a valid environment)"
+		return step
+	}
+	step.From = ver
+	if activeEnv := os.Getenv("VENGO_ENV"); activeEnv != "" &&
+		filepath.Base(activeEnv) == name {
+		step.Status = "skipped (active environment)"
+		return step
+	}
+	step.Source = cache.InstalledSource(ver)
+	current, suffix, candidates := u.candidates(ver, step.Source)
+	if candidates == nil {
+		step.Status = fmt.Sprintf("skipped (can't upgrade from %s)", step.Source)
+		return step
+	}
+	latest := newestRelease(current, candidates, u.Minor)
+	if latest == "" {
+		step.Status = "up to date"
+		return step
+	}
+	step.To = latest + suffix
+	if step.Source == "git" {
+		step.To = "go" + latest
+	}
+	// binaries built for other platforms are upgraded for the same platform
+	if metadata, err := cache.LoadMetadata(ver); err == nil {
+		step.platform = metadata.Platform
+	}
+	return step
+}
+
+// return back the numeric version of the given cached version, the suffix
+// of the cache name and the available releases for the source
+func (u *Upgrade) candidates(ver, source string) (string, string, []string) {
+	switch source {
+	case "git":
+		// dry runs list the remote tags so the Go repository is not cloned
+		listTags := cache.Tags
+		if u.DryRun {
+			listTags = cache.RemoteTags
+		}
+		tags, err := listTags(u.Log)
+		if err != nil {
+			u.Log.Warn(fmt.Sprintf("can't get the Go releases: %v", err))
+			return "", "", nil
+		}
+		candidates := []string{}
+		for _, tag := range tags {
+			if strings.HasPrefix(tag, "go") {
+				candidates = append(candidates, strings.TrimPrefix(tag, "go"))
+			}
+		}
+		return strings.TrimPrefix(ver, "go"), "", candidates
+	case "tarball":
+		return ver, "", cache.AvailableSources()
+	case "binary":
+		current := strings.Join(numericTokens(ver), ".")
+		suffix := strings.TrimPrefix(ver, current)
+		candidates := []string{}
+		for _, binary := range cache.AvailableBinaries() {
+			if strings.HasSuffix(binary, suffix) {
+				candidates = append(candidates, strings.TrimSuffix(binary, suffix))
+			}
+		}
+		return current, suffix, candidates
+	}
+	return "", "", nil
+}
+
+// install the new version and relink the environment
+func (u *Upgrade) upgrade(step *upgradeStep) error {
+	version := strings.TrimPrefix(step.To, "go")
+	if step.Source == "binary" {
+		version = strings.Join(numericTokens(step.To), ".")
+	}
+	if err := cache.Restore(u.Log, step.To); err != nil {
+		return err
+	}
+	if !cache.AlreadyCompiled(u.Log, step.To) {
+		install := NewInstall(func(i *Install) {
+			i.From = step.Source
+			i.Version = version
+			i.Log = u.Log
+			if step.Source == "binary" && step.platform != "" {
+				platform := strings.SplitN(step.platform, "/", 2)
+				if len(platform) == 2 {
+					i.OS, i.Arch = platform[0], platform[1]
+				}
+			}
+		})
+		if _, err := install.Run(); err != nil {
+			return err
+		}
+	}
+	return env.Relink(step.Environment, step.To)
+}
+
+// generates the upgrade summary table
+func (u *Upgrade) summary(steps []*upgradeStep) string {
+	buf := new(bytes.Buffer)
+	w := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Environment\tFrom\tTo\tStatus")
+	for _, step := range steps {
+		status := step.Status
+		if u.DryRun && status == "pending" {
+			status = "will be upgraded"
+		}
+		if step.err != nil {
+			status = fmt.Sprintf("%s: %v", status, step.err)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			step.Environment, step.From, step.To, status)
+	}
+	w.Flush()
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+var stableRelease = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+
+// return back the newest stable release in the same minor series (or the
+// same major series if minor is true) that is newer than current
+func newestRelease(current string, candidates []string, minor bool) string {
+	series := func(ver string) string {
+		tokens := strings.SplitN(ver, ".", 3)
+		if minor || len(tokens) < 2 {
+			return tokens[0]
+		}
+		return tokens[0] + "." + tokens[1]
+	}
+	latest := ""
+	for _, candidate := range candidates {
+		if !stableRelease.MatchString(candidate) ||
+			series(candidate) != series(current) {
+			continue
+		}
+		if !version.Compare(version.Normalize(candidate), version.Normalize(current), ">") {
+			continue
+		}
+		if latest == "" || version.Compare(
+			version.Normalize(candidate), version.Normalize(latest), ">") {
+			latest = candidate
+		}
+	}
+	return latest
+}
+
+// return back the leading version tokens of a binary cache name, e.g
+// 1.4.2.linux-amd64 gives back [1 4 2]
+func numericTokens(ver string) []string {
+	tokens := []string{}
+	for _, token := range strings.Split(ver, ".") {
+		if strings.Contains(token, "-") {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DamnWidget/VenGO/env"
+	"github.com/DamnWidget/VenGO/logger"
+)
+
+var cmdWhich = &Command{
+	Name:  "which",
+	Usage: "which [--activate shell] [directory]",
+	Short: "Show the project environment of a directory",
+	Long: `Looks for the nearest .vengo or .go-version file in the given directory, or
+the current one, and its parents and prints the name of the environment that
+applies to it. A .vengo file names the environment while a .go-version file
+applies the first environment that uses its Go version, project environments
+are not considered for .go-version files. It fails if no file is found.
+
+With --activate the path of the environment activate script for the given
+shell is printed instead, the Go version of the environment is restored if it
+was archived so the script can be sourced right away.
+
+'vengo activate' uses it when is called without arguments, and the shell
+integration of 'vengo hook-shell' on every directory change.
+`,
+	Execute: runWhich,
+}
+
+var activateWhich string
+
+// initialize the command
+func init() {
+	cmdWhich.Flag.StringVar(&activateWhich, "activate", "", "shell")
+	cmdWhich.register()
+}
+
+// run the which command
+func runWhich(cmd *Command, args ...string) error {
+	if len(args) > 1 {
+		return ErrUsage
+	}
+	w := NewWhich(func(w *Which) {
+		if len(args) == 1 {
+			w.Directory = args[0]
+		}
+		w.Activate = activateWhich
+		w.Log = cmd.Log
+	})
+	data, err := w.Run()
+	if err != nil {
+		return err
+	}
+	fmt.Println(data)
+	return nil
+}
+
+// which command
+type Which struct {
+	Directory string
+	Activate  string
+	Log       *logger.Logger
+}
+
+// Create a new which command and return back it's address
+func NewWhich(options ...func(w *Which)) *Which {
+	w := new(Which)
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
+// implements the Runner interface returning back the name of the
+// environment that applies to the directory or its activate script
+func (w *Which) Run() (string, error) {
+	dir := w.Directory
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return "", err
+		}
+	}
+	name, err := env.ProjectEnvironment(dir)
+	if err != nil {
+		if err == env.ErrNoMarker {
+			return "", suggestError(
+				fmt.Errorf("%s is not inside a project environment", dir),
+				"create one with 'vengo mkenv --here'")
+		}
+		return "", err
+	}
+	if w.Activate == "" {
+		return name, nil
+	}
+	environment, err := loadRestoredEnvironment(name, w.Log)
+	if err != nil {
+		return "", err
+	}
+	return environment.ActivateScript(w.Activate)
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DamnWidget/VenGO/cache"
+)
+
+// name of the configuration file stored inside every environment
+const ConfigFile = "vengo.json"
+
+// environment configuration structure, the activate scripts are generated
+// from it and commands read it instead of parsing the activate scripts
+type Config struct {
+	Name      string            `json:"name"`
+	Prompt    string            `json:"prompt"`
+	GoVersion string            `json:"go_version"`
+	Created   time.Time         `json:"created"`
+	Env       map[string]string `json:"env,omitempty"`
+	Hooks     map[string]string `json:"hooks,omitempty"`
+	Shells    []string          `json:"shells,omitempty"`
+	Project   string            `json:"project,omitempty"`
+}
+
+// return back the directory of the given environment, names are looked up
+// in the VenGO home while absolute paths are used as they are
+func EnvironmentPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(cache.VenGO_PATH, name)
+}
+
+// load the configuration of the given environment, environments created
+// before the configuration file existed get one built from their directory
+func LoadConfig(name string) (*Config, error) {
+	envPath := EnvironmentPath(name)
+	data, err := ioutil.ReadFile(filepath.Join(envPath, ConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return legacyConfig(envPath)
+		}
+		return nil, err
+	}
+	c := new(Config)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("malformed %s in %s: %v", ConfigFile, envPath, err)
+	}
+	return c, nil
+}
+
+// build the configuration of an environment that has no configuration file
+func legacyConfig(envPath string) (*Config, error) {
+	activate := filepath.Join(envPath, "bin", "activate")
+	info, err := os.Stat(activate)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(envPath)
+	c := &Config{Name: name, Prompt: legacyPrompt(activate), Created: info.ModTime()}
+	if c.Prompt == "" {
+		c.Prompt = name
+	}
+	if lib, err := os.Readlink(filepath.Join(envPath, "lib")); err == nil {
+		c.GoVersion = filepath.Base(lib)
+	}
+	return c, nil
+}
+
+// recover the prompt from the PS1 line of the given activate script, an
+// empty string is returned if it can't be found
+func legacyPrompt(activate string) string {
+	data, err := ioutil.ReadFile(activate)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "PS1=") ||
+			!strings.HasSuffix(line, ` ${_VENGO_PREV_PS1}"`) {
+			continue
+		}
+		// PS1="prompt ${_VENGO_PREV_PS1}" or PS1="prompt"" ${_VENGO_PREV_PS1}"
+		prompt := strings.TrimSuffix(line[len("PS1="):], ` ${_VENGO_PREV_PS1}"`)
+		return strings.Trim(prompt, `"`)
+	}
+	return ""
+}
+
+// write the configuration into the given environment directory
+func (c *Config) Save(envPath string) error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(envPath, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(envPath, ConfigFile), data, 0644)
+}
@@ -23,33 +23,57 @@ package env
 import (
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/DamnWidget/VenGO/cache"
+	"github.com/DamnWidget/VenGO/logger"
 )
 
-var environTemplate = "tpl/activate"
+// Go modules and build cache variables that can be set per environment
+// using their own mkenv flags
+var ModuleVariables = []string{
+	"GOMODCACHE", "GOCACHE", "GOFLAGS", "GOPROXY", "GONOSUMDB", "GO111MODULE",
+}
+
+// variables managed by the activate scripts that can't be customized
+var reservedVariables = []string{
+	"VENGO_ENV", "GOROOT", "GOTOOLDIR", "GOPATH", "PATH", "PS1",
+}
+
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 
 type Environment struct {
+	Name       string
+	GoVersion  string
+	Created    time.Time
 	Goroot     string
 	Gotooldir  string
 	Gopath     string
 	PS1        string
 	VenGO_PATH string
+	Env        map[string]string
+	Hooks      map[string]string
+	Shells     []string
+	Project    string
+	Log        *logger.Logger
+	previous   map[string]*string
 }
 
 // Create a new Environment struct and return it addrees back
 func NewEnvironment(name, prompt string) *Environment {
-	VenGO_PATH := filepath.Join(cache.VenGO_PATH, name)
+	VenGO_PATH := EnvironmentPath(name)
 	osArch := fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH)
 	return &Environment{
+		Name:       filepath.Base(VenGO_PATH),
+		Created:    time.Now(),
 		Goroot:     filepath.Join(VenGO_PATH, "lib"),
 		Gotooldir:  filepath.Join(VenGO_PATH, "lib", "pkg", "tool", osArch),
 		Gopath:     VenGO_PATH,
@@ -58,6 +82,77 @@ func NewEnvironment(name, prompt string) *Environment {
 	}
 }
 
+// load an existing environment from its configuration file
+func LoadEnvironment(name string) (*Environment, error) {
+	c, err := LoadConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	e := NewEnvironment(name, c.Prompt)
+	e.GoVersion = c.GoVersion
+	e.Created = c.Created
+	e.Hooks = c.Hooks
+	e.Shells = c.Shells
+	if c.Project != "" {
+		e.Project, e.Gopath = c.Project, c.Project
+	}
+	for variable, value := range c.Env {
+		if err := e.SetVariable(variable, value); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// return back the configuration of the environment
+func (e *Environment) Config() *Config {
+	return &Config{
+		Name:      e.Name,
+		Prompt:    e.PS1,
+		GoVersion: e.GoVersion,
+		Created:   e.Created,
+		Env:       e.Env,
+		Hooks:     e.Hooks,
+		Shells:    e.Shells,
+		Project:   e.Project,
+	}
+}
+
+// write the environment configuration file
+func (e *Environment) SaveConfig() error {
+	return e.Config().Save(e.VenGO_PATH)
+}
+
+// set the given variable in the environment, the activate scripts export it
+// and restore its previous value on deactivate
+func (e *Environment) SetVariable(name, value string) error {
+	if !variableName.MatchString(name) {
+		return fmt.Errorf("%s is not a valid environment variable name", name)
+	}
+	for _, reserved := range reservedVariables {
+		if reserved == name {
+			return fmt.Errorf("%s is managed by VenGO and can't be set", name)
+		}
+	}
+	if strings.HasPrefix(name, "_VENGO_") {
+		return fmt.Errorf("%s is reserved for VenGO internal use", name)
+	}
+	if e.Env == nil {
+		e.Env = map[string]string{}
+	}
+	e.Env[name] = value
+	return nil
+}
+
+// remove the given variable from the environment
+func (e *Environment) UnsetVariable(name string) error {
+	if _, ok := e.Env[name]; !ok {
+		return fmt.Errorf("%s is not set in %s", name, e.Name)
+	}
+	delete(e.Env, name)
+	return nil
+}
+
 // checks if a environment already exists
 func (e *Environment) Exists() bool {
 	if _, err := os.Stat(e.VenGO_PATH); err != nil {
@@ -68,91 +163,103 @@ func (e *Environment) Exists() bool {
 	return true
 }
 
-// generate an environment file
+// generate the environment configuration and the activate scripts of its
+// shells, scripts of shells that are not used anymore are removed
 func (e *Environment) Generate() error {
-	if err := e.generateBash(); err != nil {
+	if err := e.SaveConfig(); err != nil {
 		return err
 	}
-	return e.generateFish()
-}
-
-func (e *Environment) generateBash() error {
-	if os.Getenv("VENGO_HOME") != "" {
-		environTemplate = filepath.Join(
-			os.Getenv("VENGO_HOME"), "scripts", "tpl", "activate")
-	} else {
-		if environTemplate == "tpl/activate" {
-			_, caller, _, ok := runtime.Caller(1)
-			if ok {
-				// we are running in a test environment
-				environTemplate = filepath.Join(
-					path.Dir(caller), "..", "env", environTemplate)
+	selected := e.shellTypes()
+	for _, shell := range shellTypes {
+		used := false
+		for _, s := range selected {
+			used = used || s == shell
+		}
+		for _, script := range shell.scripts {
+			filename := filepath.Join(e.VenGO_PATH, "bin", script)
+			if !used {
+				if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				continue
+			}
+			if err := e.generateScript(shell, script); err != nil {
+				return err
 			}
 		}
 	}
-	file, err := e.checkPath()
+	return nil
+}
+
+// generate the given script of the shell from its template
+func (e *Environment) generateScript(shell *shellType, script string) error {
+	file, err := e.createFile(filepath.Join(e.VenGO_PATH, "bin", script))
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	activateTpl, err := ioutil.ReadFile(environTemplate)
+	activateTpl, err := readTemplate(script)
 	if err != nil {
-		fmt.Println("while reading activate script template file:", err)
+		e.Log.Error(fmt.Sprintf("while reading %s script template file: %v", script, err))
 		return err
 	}
-	tpl := template.Must(template.New("environment").Parse(string(activateTpl)))
-	err = tpl.Execute(file, e)
+	tpl, err := template.New(script).Funcs(
+		template.FuncMap{"quote": shell.quote}).Parse(string(activateTpl))
 	if err != nil {
-		fmt.Println("while generating environment template:", err)
+		e.Log.Error(fmt.Sprintf("while parsing %s script template: %v", script, err))
+		return err
+	}
+	err = tpl.Execute(file, &activateData{e, shell})
+	if err != nil {
+		e.Log.Error(fmt.Sprintf("while generating environment template: %v", err))
 		return err
 	}
 
 	return nil
 }
 
-func (e *Environment) generateFish() error {
-	if os.Getenv("VENGO_HOME") != "" {
-		environTemplate = filepath.Join(
-			os.Getenv("VENGO_HOME"), "scripts", "tpl", "activate.fish")
-	} else {
-		if environTemplate == "tpl/activate.fish" {
-			_, caller, _, ok := runtime.Caller(1)
-			if ok {
-				// we are running in a test environment
-				environTemplate = filepath.Join(
-					path.Dir(caller), "..", "env", environTemplate)
-			}
-		}
+// return back the shell backends used by the environment, all of them if
+// no one has been selected
+func (e *Environment) shellTypes() []*shellType {
+	if len(e.Shells) == 0 {
+		return shellTypes
 	}
-	file, err := e.checkPath(struct{}{})
-	if err != nil {
-		return err
+	selected := []*shellType{}
+	for _, name := range e.Shells {
+		if shell, err := lookupShell(name); err == nil {
+			selected = append(selected, shell)
+		}
 	}
-	defer file.Close()
-	activateTpl, err := ioutil.ReadFile(environTemplate)
+	return selected
+}
+
+// return back the path of the activate script of the given shell, it is
+// generated if the environment doesn't use the shell
+func (e *Environment) ActivateScript(name string) (string, error) {
+	shell, err := lookupShell(name)
 	if err != nil {
-		fmt.Println("while reading activate script template file:", err)
-		return err
+		return "", err
 	}
-	tpl := template.Must(template.New("environment").Parse(string(activateTpl)))
-	err = tpl.Execute(file, e)
-	if err != nil {
-		fmt.Println("while generating environment template:", err)
-		return err
+	for _, script := range shell.scripts {
+		filename := filepath.Join(e.VenGO_PATH, "bin", script)
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			if err := e.generateScript(shell, script); err != nil {
+				return "", err
+			}
+		}
 	}
-
-	return nil
+	return filepath.Join(e.VenGO_PATH, "bin", shell.scripts[0]), nil
 }
 
-// checks if the environment path exists, and create it if doesn't
-// returns a file value or error if fails
-func (e *Environment) checkPath(fish ...struct{}) (*os.File, error) {
-	sh := "activate"
-	if len(fish) != 0 {
-		sh = "activate.fish"
+// select the shells whose activate scripts are generated
+func (e *Environment) SetShells(names []string) error {
+	for _, name := range names {
+		if _, err := lookupShell(name); err != nil {
+			return err
+		}
 	}
-	fileName := filepath.Join(e.VenGO_PATH, "bin", sh)
-	return e.createFile(fileName)
+	e.Shells = names
+	return nil
 }
 
 func (e *Environment) createFile(filename string) (*os.File, error) {
@@ -173,16 +280,24 @@ func (e *Environment) createFile(filename string) (*os.File, error) {
 
 // install the given version into the environment creating a Symlink to it
 func (e *Environment) Install(ver string) error {
-	if !cache.AlreadyCompiled(ver) {
-		if err := cache.Compile(ver, false, false); err != nil {
-			fmt.Println("while installing:", err)
+	archived := ver
+	if !cache.Exists(archived) {
+		archived = fmt.Sprintf("go%s", ver)
+	}
+	if err := cache.Restore(e.Log, archived); err != nil {
+		e.Log.Error(fmt.Sprintf("while restoring: %v", err))
+		return err
+	}
+	if !cache.AlreadyCompiled(e.Log, ver) {
+		if err := cache.Compile(e.Log, ver, false, false); err != nil {
+			e.Log.Error(fmt.Sprintf("while installing: %v", err))
 			return err
 		}
 	}
 
-	path := filepath.Join(cache.CacheDirectory(), ver)
+	path := cache.VersionPath(ver)
 	if _, err := os.Stat(path); err != nil {
-		path = filepath.Join(cache.CacheDirectory(), fmt.Sprintf("go%s", ver))
+		path = cache.VersionPath(fmt.Sprintf("go%s", ver))
 	}
 
 	link := func() error {
@@ -193,11 +308,11 @@ func (e *Environment) Install(ver string) error {
 		if os.IsExist(err) {
 			os.Remove(filepath.Join(e.VenGO_PATH, "lib"))
 			if err := link(); err != nil {
-				fmt.Println("while creating symlink:", err)
+				e.Log.Error(fmt.Sprintf("while creating symlink: %v", err))
 				return err
 			}
 		} else {
-			fmt.Println("while creating symlink:", err)
+			e.Log.Error(fmt.Sprintf("while creating symlink: %v", err))
 			return err
 		}
 	}
@@ -220,8 +335,8 @@ func (e *Environment) Packages(environment ...string) ([]*Package, error) {
 		basePath,
 		func(walkPath string, info os.FileInfo, err error) error {
 			if err != nil {
-				fmt.Fprintf(cache.Output,
-					"%s ignored because error: %s\n", walkPath, err)
+				e.Log.Warn(fmt.Sprintf(
+					"%s ignored because error: %s", walkPath, err))
 				return nil
 			}
 			if !info.IsDir() {
@@ -241,7 +356,8 @@ func (e *Environment) Packages(environment ...string) ([]*Package, error) {
 							// we are in the test suite
 							out = []byte{}
 						} else {
-							log.Printf("warning %s skypped: %s", walkPath, string(out))
+							e.Log.Warn(fmt.Sprintf(
+								"%s skypped: %s", walkPath, string(out)))
 							return nil
 						}
 					}
@@ -283,18 +399,56 @@ func (e *Environment) Manifest() (*envManifest, error) {
 	return NewEnvManifest(e, general, goVersion)
 }
 
-// activate environment in the current process
-func (e *Environment) activate() {
-	os.Setenv("VENGO_ENV", cache.ExpandUser(e.VenGO_PATH))
-	os.Setenv("GOTOOLDIR", cache.ExpandUser(e.Gotooldir))
-	os.Setenv("GORROT", cache.ExpandUser(e.Goroot))
-	os.Setenv("GOPATH", cache.ExpandUser(e.Gopath))
+// activate the environment in the current process, so the commands that
+// are executed from it use the environment Go version, GOPATH and custom
+// variables, previous values are kept to restore them on Deactivate
+func (e *Environment) Activate() {
+	if e.previous != nil {
+		return
+	}
+	goroot := cache.ExpandUser(e.Goroot)
+	gopath := cache.ExpandUser(e.Gopath)
+	variables := map[string]string{
+		"VENGO_ENV": cache.ExpandUser(e.VenGO_PATH),
+		"GOROOT":    goroot,
+		"GOTOOLDIR": cache.ExpandUser(e.Gotooldir),
+		"GOPATH":    gopath,
+		"PATH": strings.Join([]string{
+			filepath.Join(goroot, "bin"),
+			filepath.Join(gopath, "bin"),
+			os.Getenv("PATH"),
+		}, string(os.PathListSeparator)),
+	}
+	for name, value := range e.Env {
+		variables[name] = value
+	}
+	e.previous = map[string]*string{}
+	for name, value := range variables {
+		if previous, ok := os.LookupEnv(name); ok {
+			e.previous[name] = &previous
+		} else {
+			e.previous[name] = nil
+		}
+		os.Setenv(name, value)
+	}
+
+	// record the activation so unused Go versions can be archived
+	now := time.Now()
+	activated := filepath.Join(e.VenGO_PATH, activatedFile)
+	if err := os.Chtimes(activated, now, now); os.IsNotExist(err) {
+		ioutil.WriteFile(activated, nil, 0644)
+	}
 }
 
-// deactivate environment in the current process
-func (e *Environment) deactivate() {
-	os.Setenv("VENGO_ENV", "")
-	os.Setenv("GOTOOLDIR", "")
-	os.Setenv("GOROOT", "")
-	os.Setenv("GOPATH", "")
+// deactivate the environment in the current process restoring the values
+// that the variables had before it was activated
+func (e *Environment) Deactivate() {
+	for name, value := range e.previous {
+		if value == nil {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, *value)
+		}
+	}
+	e.previous = nil
 }
@@ -23,12 +23,11 @@ package env
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 
 	"github.com/DamnWidget/VenGO/cache"
-	"github.com/DamnWidget/VenGO/utils"
+	"github.com/DamnWidget/VenGO/logger"
 )
 
 // environment manifest structure
@@ -96,13 +95,17 @@ func LoadManifest(manifestFile string) (*envManifest, error) {
 }
 
 // Generate an environment using it's manifest
-func (em *envManifest) GenerateEnvironment(v bool, prompt string) error {
+func (em *envManifest) GenerateEnvironment(log *logger.Logger, v bool, prompt string) error {
 	// install go version if it's not installed yet
-	if !LookupInstalledVersion(em.GoVersion) {
-		if err := cache.CacheDownloadGit(em.GoVersion); err != nil {
+	installed, err := LookupInstalledVersion(log, em.GoVersion)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		if err := cache.CacheDownloadGit(log, em.GoVersion); err != nil {
 			return err
 		}
-		if err := cache.Compile(em.GoVersion, v, false); err != nil {
+		if err := cache.Compile(log, em.GoVersion, v, false); err != nil {
 			return err
 		}
 	}
@@ -110,25 +113,27 @@ func (em *envManifest) GenerateEnvironment(v bool, prompt string) error {
 		prompt = fmt.Sprintf("[%s]", em.Name)
 	}
 	impEnv := NewEnvironment(em.Name, prompt)
+	impEnv.GoVersion = em.GoVersion
+	impEnv.Log = log
 	if err := impEnv.Generate(); err != nil {
-		os.RemoveAll(filepath.Join(os.Getenv("VENGO_HOME"), em.Name))
+		os.RemoveAll(filepath.Join(cache.VenGO_PATH, em.Name))
 		return err
 	}
 	if err := impEnv.Install(em.GoVersion); err != nil {
-		os.RemoveAll(filepath.Join(os.Getenv("VENGO_HOME"), em.Name))
+		os.RemoveAll(filepath.Join(cache.VenGO_PATH, em.Name))
 		return err
 	}
-	impEnv.activate()
-	defer impEnv.deactivate()
-	if err := em.installPackages(v); err != nil {
-		os.RemoveAll(filepath.Join(os.Getenv("VENGO_HOME"), em.Name))
+	impEnv.Activate()
+	defer impEnv.Deactivate()
+	if err := em.installPackages(log, v); err != nil {
+		os.RemoveAll(filepath.Join(cache.VenGO_PATH, em.Name))
 		return err
 	}
 	return nil
 }
 
 // install all the packages in the manifest using their respective revisions
-func (em *envManifest) installPackages(v bool) error {
+func (em *envManifest) installPackages(log *logger.Logger, v bool) error {
 	curr, _ := os.Getwd()
 	environmentPath := filepath.Join(os.Getenv("VENGO_ENV"), "src")
 	if err := os.MkdirAll(environmentPath, 0755); err != nil {
@@ -140,29 +145,33 @@ func (em *envManifest) installPackages(v bool) error {
 		if pkg.CodeRevision == "0000000000000000000000000000000000000000" {
 			continue // we are in a test here
 		}
-		fmt.Printf("Cloning %s... ", pkg.Name)
+		step := log.Step(fmt.Sprintf("Cloning %s", pkg.Name))
 		if err := pkg.Vcs.Clone(pkg.Url, pkg.CodeRevision, pkg.Root, v); err != nil {
-			fmt.Println(utils.Fail("✖"))
+			step.Fail(err)
 			return err
 		}
-		fmt.Println(utils.Ok("✔"))
+		step.Ok()
 	}
 	return nil
 }
 
 // lookup for an specific installed go version
-func LookupInstalledVersion(version string) bool {
+func LookupInstalledVersion(log *logger.Logger, version string) (bool, error) {
+	tags, err := cache.Tags(log)
+	if err != nil {
+		return false, err
+	}
 	installed, err := cache.GetInstalled(
-		cache.Tags(), cache.AvailableSources(), cache.AvailableBinaries())
+		tags, cache.AvailableSources(), cache.AvailableBinaries())
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
 
 	for _, v := range installed {
 		if v == version {
-			return true
+			return true, nil
 		}
 	}
 
-	return false
+	return false, nil
 }
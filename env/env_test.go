@@ -1,13 +1,14 @@
 package env_test
 
 import (
-	"bytes"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/DamnWidget/VenGO/cache"
 	"github.com/DamnWidget/VenGO/env"
@@ -19,7 +20,6 @@ var RunSlowTests = false
 var _ = Describe("Env", func() {
 
 	// disable log output
-	cache.Output = ioutil.Discard
 	log.SetOutput(ioutil.Discard)
 
 	BeforeSuite(func() {
@@ -57,27 +57,27 @@ var _ = Describe("Env", func() {
 				activate, err := ioutil.ReadFile(filepath.Join(e.VenGO_PATH, "bin", "activate"))
 
 				Expect(err).ToNot(HaveOccurred())
-				byteLines := bytes.Split(activate, []byte("\n"))
+				lines := strings.Split(string(activate), "\n")
 				vengoPath := fmt.Sprintf(`VENGO_ENV="%s/goTest"`, cache.VenGO_PATH)
 				sysPath := fmt.Sprint(`PATH="$GOROOT/bin:$GOPATH/bin:$PATH"`)
 				goRoot := fmt.Sprintf(`GOROOT="%s"`, e.Goroot)
 				goTooldir := fmt.Sprintf(`GOTOOLDIR="%s"`, e.Gotooldir)
 				goPath := fmt.Sprintf(`GOPATH="%s"`, e.VenGO_PATH)
-				ps1 := fmt.Sprintf(`PS1="%s ${_VENGO_PREV_PS1}"`, e.PS1)
-
-				Expect(byteLines[53]).To(Equal([]byte(vengoPath)))
-				Expect(byteLines[73]).To(Equal([]byte(goRoot)))
-				Expect(byteLines[83]).To(Equal([]byte(sysPath)))
-				Expect(byteLines[76]).To(Equal([]byte(goTooldir)))
-				Expect(byteLines[79]).To(Equal([]byte(goPath)))
-				Expect(byteLines[86]).To(Equal([]byte(ps1)))
+				ps1 := fmt.Sprintf(`	PS1="%s"" ${_VENGO_PREV_PS1}"`, e.PS1)
+
+				Expect(lines).To(ContainElement(vengoPath))
+				Expect(lines).To(ContainElement(goRoot))
+				Expect(lines).To(ContainElement(sysPath))
+				Expect(lines).To(ContainElement(goTooldir))
+				Expect(lines).To(ContainElement(goPath))
+				Expect(lines).To(ContainElement(ps1))
 				os.RemoveAll(e.VenGO_PATH)
 			})
 		})
 
 		Describe("Install", func() {
 			It("Will create a symboolic link into VenGO_PATH", func() {
-				Expect(cache.CacheDownloadGit("1.3.2")).To(Succeed())
+				Expect(cache.CacheDownloadGit(nil, "1.3.2")).To(Succeed())
 
 				name := "goTest"
 				prompt := "(gotest)"
@@ -91,6 +91,301 @@ var _ = Describe("Env", func() {
 		})
 	}
 
+	Describe("Variables", func() {
+		It("Should export the environment variables in the activate scripts", func() {
+			e := env.NewEnvironment("goTestVariables", "(goTestVariables)")
+			Expect(e.SetVariable("GOPROXY", "https://proxy.golang.org")).To(Succeed())
+			Expect(e.SetVariable("CGO_CFLAGS", "-I/opt/include")).To(Succeed())
+			Expect(e.SetVariable("GOPATH", "/tmp")).ToNot(Succeed())
+			Expect(e.SetVariable("_VENGO_PREV_PS1", "")).ToNot(Succeed())
+			Expect(e.SetVariable("NOT-VALID", "")).ToNot(Succeed())
+			Expect(e.UnsetVariable("CGO_LDFLAGS")).ToNot(Succeed())
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+
+			activate, err := ioutil.ReadFile(filepath.Join(e.VenGO_PATH, "bin", "activate"))
+			Expect(err).ToNot(HaveOccurred())
+			lines := strings.Split(string(activate), "\n")
+			Expect(lines).To(ContainElement(`GOPROXY="https://proxy.golang.org"`))
+			Expect(lines).To(ContainElement(`CGO_CFLAGS="-I/opt/include"`))
+			Expect(lines).To(ContainElement(`            unset CGO_CFLAGS`))
+			Expect(lines).ToNot(ContainElement(`            unset GOMODCACHE`))
+
+			Expect(e.UnsetVariable("CGO_CFLAGS")).To(Succeed())
+			Expect(e.Env).To(Equal(map[string]string{"GOPROXY": "https://proxy.golang.org"}))
+		})
+	})
+
+	Describe("Shells", func() {
+		It("Should generate the activate scripts of every shell", func() {
+			e := env.NewEnvironment("goTestShells", `(go "Test" $Shells)`)
+			Expect(e.SetVariable("API_URL", "http://localhost:8080")).To(Succeed())
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+
+			expected := map[string]string{
+				"activate":       `PS1="(go \"Test\" \$Shells)"" ${_VENGO_PREV_PS1}"`,
+				"activate.zsh":   `export API_URL="http://localhost:8080"`,
+				"activate.fish":  `set -gx API_URL "http://localhost:8080"`,
+				"activate.csh":   `setenv API_URL 'http://localhost:8080'`,
+				"deactivate.csh": `unsetenv _VENGO_SET_API_URL`,
+				"activate.nu":    `API_URL: "http://localhost:8080"`,
+				"activate.ps1":   `$env:API_URL = 'http://localhost:8080'`,
+			}
+			for script, line := range expected {
+				data, err := ioutil.ReadFile(filepath.Join(e.VenGO_PATH, "bin", script))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring(line))
+			}
+		})
+
+		It("Should generate only the activate scripts of the selected shells", func() {
+			e := env.NewEnvironment("goTestSomeShells", "(goTestSomeShells)")
+			Expect(e.SetShells([]string{"bash", "cmd"})).ToNot(Succeed())
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+			Expect(e.SetShells([]string{"bash", "fish"})).To(Succeed())
+			Expect(e.Generate()).To(Succeed())
+
+			scripts, err := filepath.Glob(filepath.Join(e.VenGO_PATH, "bin", "*"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(scripts).To(Equal([]string{
+				filepath.Join(e.VenGO_PATH, "bin", "activate"),
+				filepath.Join(e.VenGO_PATH, "bin", "activate.fish"),
+			}))
+			loaded, err := env.LoadEnvironment("goTestSomeShells")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Shells).To(Equal([]string{"bash", "fish"}))
+		})
+	})
+
+	Describe("Templates", func() {
+		It("Should use the templates of the user instead of the built in ones", func() {
+			Expect(os.MkdirAll(env.TemplatesDirectory(), 0755)).To(Succeed())
+			defer os.RemoveAll(env.TemplatesDirectory())
+			Expect(ioutil.WriteFile(filepath.Join(env.TemplatesDirectory(), "activate"),
+				[]byte("export CUSTOM={{ quote .Name }}\n"), 0644)).To(Succeed())
+
+			e := env.NewEnvironment("goTestTemplates", "(goTestTemplates)")
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+
+			activate, err := ioutil.ReadFile(filepath.Join(e.VenGO_PATH, "bin", "activate"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(activate)).To(Equal("export CUSTOM=\"goTestTemplates\"\n"))
+			activate, err = ioutil.ReadFile(filepath.Join(e.VenGO_PATH, "bin", "activate.fish"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(activate)).To(ContainSubstring("function deactivate"))
+		})
+	})
+
+	Describe("Hooks", func() {
+		It("Should reference the hook scripts from the activate scripts", func() {
+			e := env.NewEnvironment("goTestHooks", "(goTestHooks)")
+			Expect(e.SetHook("postactivate", "/opt/project/postactivate")).To(Succeed())
+			Expect(e.SetHook("postinstall", "/opt/project/postinstall")).ToNot(Succeed())
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+
+			activate, err := ioutil.ReadFile(filepath.Join(e.VenGO_PATH, "bin", "activate"))
+			Expect(err).ToNot(HaveOccurred())
+			lines := strings.Split(string(activate), "\n")
+			Expect(lines).To(ContainElement(`_vengo_run_hook "/opt/project/postactivate"`))
+			Expect(lines).To(ContainElement(fmt.Sprintf(
+				`_vengo_run_hook "%s"`, env.GlobalHookPath("preactivate"))))
+			Expect(lines).To(ContainElement(fmt.Sprintf(
+				`        _vengo_run_hook "%s"`, filepath.Join(e.VenGO_PATH, "hooks", "predeactivate"))))
+
+			loaded, err := env.LoadEnvironment("goTestHooks")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.HookPath("postactivate")).To(Equal("/opt/project/postactivate"))
+			Expect(loaded.SetHook("postactivate", "")).To(Succeed())
+			Expect(loaded.HookPath("postactivate")).To(Equal(
+				filepath.Join(e.VenGO_PATH, "hooks", "postactivate")))
+		})
+
+		It("Should source the hook scripts when the activate script is sourced", func() {
+			e := env.NewEnvironment("goTestRunHooks", "(goTestRunHooks)")
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+			hooks := filepath.Join(e.VenGO_PATH, "hooks")
+			output := filepath.Join(e.VenGO_PATH, "hooks.log")
+			Expect(os.MkdirAll(hooks, 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(hooks, "postactivate"),
+				[]byte(fmt.Sprintf("echo \"post $VENGO_ENV\" >> %q\n", output)), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(hooks, "predeactivate"),
+				[]byte(fmt.Sprintf("echo pre >> %q\n", output)), 0644)).To(Succeed())
+
+			activate := filepath.Join(e.VenGO_PATH, "bin", "activate")
+			cmd := exec.Command("sh", "-c", `. "$1" && deactivate`, "sh", activate)
+			out, err := cmd.CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(out))
+			data, err := ioutil.ReadFile(output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(fmt.Sprintf("post %s\npre\n", e.VenGO_PATH)))
+		})
+	})
+
+	Describe("LoadEnvironment", func() {
+		It("Should load the environment from its configuration file", func() {
+			e := env.NewEnvironment("goTestConfig", "[{(goTestConfig)}]")
+			e.GoVersion = "1.4"
+			Expect(e.SetVariable("GO111MODULE", "on")).To(Succeed())
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+
+			loaded, err := env.LoadEnvironment("goTestConfig")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Name).To(Equal("goTestConfig"))
+			Expect(loaded.PS1).To(Equal("[{(goTestConfig)}]"))
+			Expect(loaded.GoVersion).To(Equal("1.4"))
+			Expect(loaded.Env).To(Equal(map[string]string{"GO111MODULE": "on"}))
+			Expect(loaded.Created.Equal(e.Created)).To(BeTrue())
+
+			Expect(env.Relink("goTestConfig", "test-version")).To(Succeed())
+			loaded, err = env.LoadEnvironment("goTestConfig")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.GoVersion).To(Equal("test-version"))
+		})
+
+		It("Should load environments without configuration file", func() {
+			e := env.NewEnvironment("goTestLegacy", "(goTestLegacy)")
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+			Expect(os.Remove(filepath.Join(e.VenGO_PATH, env.ConfigFile))).To(Succeed())
+
+			loaded, err := env.LoadEnvironment("goTestLegacy")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.PS1).To(Equal("(goTestLegacy)"))
+
+			activate := "PS1=\"[legacy] ${_VENGO_PREV_PS1}\"\nexport PS1\n"
+			Expect(ioutil.WriteFile(filepath.Join(e.VenGO_PATH, "bin", "activate"),
+				[]byte(activate), 0644)).To(Succeed())
+			loaded, err = env.LoadEnvironment("goTestLegacy")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.PS1).To(Equal("[legacy]"))
+
+			_, err = env.LoadEnvironment("goTestMissing")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Projects", func() {
+		It("Should use the project directory as GOPATH and find its marker file", func() {
+			project, err := ioutil.TempDir("", "VenGOProjectTest")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(project)
+
+			e := env.NewEnvironment("goTestProject", "(goTestProject)")
+			e.GoVersion = "go1.4"
+			Expect(e.SetProject(project)).To(Succeed())
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+			Expect(e.WriteMarker()).To(Succeed())
+
+			loaded, err := env.LoadEnvironment("goTestProject")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Project).To(Equal(project))
+			Expect(loaded.Gopath).To(Equal(project))
+
+			subdir := filepath.Join(project, "cmd", "server")
+			Expect(os.MkdirAll(subdir, 0755)).To(Succeed())
+			path, err := env.FindMarker(subdir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal(filepath.Join(project, env.MarkerFile)))
+			marker, err := env.LoadMarker(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(marker).To(Equal(&env.Marker{
+				Environment: "goTestProject", GoVersion: "go1.4"}))
+
+			Expect(os.Remove(path)).To(Succeed())
+			_, err = env.FindMarker(subdir)
+			Expect(err).To(Equal(env.ErrNoMarker))
+		})
+	})
+
+	Describe("ProjectEnvironment", func() {
+		It("Should use the environments of the Go version in .go-version files", func() {
+			project, err := ioutil.TempDir("", "VenGOGoVersionTest")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(project)
+
+			e := env.NewEnvironment("goTestGoVersion", "(goTestGoVersion)")
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+			Expect(env.Relink("goTestGoVersion", "go1.16")).To(Succeed())
+
+			file := filepath.Join(project, env.GoVersionFile)
+			Expect(ioutil.WriteFile(file, []byte("1.16\n"), 0644)).To(Succeed())
+			name, err := env.ProjectEnvironment(project)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("goTestGoVersion"))
+
+			Expect(ioutil.WriteFile(file, []byte("1.16.3\n"), 0644)).To(Succeed())
+			name, err = env.ProjectEnvironment(project)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("goTestGoVersion"))
+
+			Expect(ioutil.WriteFile(file, []byte("1.4\n"), 0644)).To(Succeed())
+			_, err = env.ProjectEnvironment(project)
+			Expect(err).To(HaveOccurred())
+
+			marker := &env.Marker{Environment: "goTestProject"}
+			Expect(marker.Save(project)).To(Succeed())
+			name, err = env.ProjectEnvironment(project)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("goTestProject"))
+		})
+	})
+
+	Describe("VersionEnvironment", func() {
+		It("Should prefer the exact version and skip project environments", func() {
+			project, err := ioutil.TempDir("", "VenGOVersionEnvironmentTest")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(project)
+
+			p := env.NewEnvironment("goTestAProject", "(goTestAProject)")
+			Expect(p.SetProject(project)).To(Succeed())
+			Expect(p.Generate()).To(Succeed())
+			defer os.RemoveAll(p.VenGO_PATH)
+			Expect(env.Relink("goTestAProject", "go1.16.3")).To(Succeed())
+			for name, ver := range map[string]string{
+				"goTestBMinor": "go1.16", "goTestCExact": "go1.16.3"} {
+				e := env.NewEnvironment(name, "("+name+")")
+				Expect(e.Generate()).To(Succeed())
+				defer os.RemoveAll(e.VenGO_PATH)
+				Expect(env.Relink(name, ver)).To(Succeed())
+			}
+
+			name, err := env.VersionEnvironment("1.16.3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("goTestCExact"))
+			name, err = env.VersionEnvironment("go1.16.5")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("goTestBMinor"))
+		})
+	})
+
+	Describe("Dependents", func() {
+		It("Should return the environments linked to a Go version", func() {
+			e := env.NewEnvironment("goTestDependents", "(goTestDependents)")
+			Expect(e.Generate()).To(Succeed())
+			defer os.RemoveAll(e.VenGO_PATH)
+
+			Expect(env.Relink("goTestDependents", "test-version")).To(Succeed())
+			linked, err := env.LinkedVersion("goTestDependents")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(linked).To(Equal("test-version"))
+
+			dependents, err := env.Dependents("test-version")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dependents).To(Equal([]string{"goTestDependents"}))
+			dependents, err = env.Dependents("other-version")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dependents).To(BeEmpty())
+		})
+	})
+
 	Describe("NewPackage", func() {
 		It("Will return a configured package", func() {
 			options := func(p *env.Package) {
@@ -320,7 +615,7 @@ var _ = Describe("Env", func() {
 
 					Expect(err).ToNot(HaveOccurred())
 					Expect(manifest).ToNot(BeNil())
-					Expect(manifest.GenerateEnvironment(false, "(prompt)")).To(Succeed())
+					Expect(manifest.GenerateEnvironment(nil, false, "(prompt)")).To(Succeed())
 				})
 			})
 
@@ -343,7 +638,7 @@ var _ = Describe("Env", func() {
 						Expect(err).ToNot(HaveOccurred())
 						Expect(manifest).ToNot(BeNil())
 
-						Expect(manifest.GenerateEnvironment(false, "(prompt)")).To(Succeed())
+						Expect(manifest.GenerateEnvironment(nil, false, "(prompt)")).To(Succeed())
 					})
 				})
 			}
@@ -0,0 +1,148 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/DamnWidget/VenGO/cache"
+)
+
+// file touched by the activate scripts every time an environment is activated
+const activatedFile = ".vengo-activated"
+
+// return back the names of the VenGO environments in the system
+func Environments() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(cache.VenGO_PATH, "*"))
+	if err != nil {
+		return nil, err
+	}
+	environments := []string{}
+	for _, file := range files {
+		name := filepath.Base(file)
+		if name == "bin" || name == "scripts" {
+			continue
+		}
+		if isEnvironment(file) {
+			environments = append(environments, name)
+		}
+	}
+	return environments, nil
+}
+
+// check if the given directory looks like an environment, its config file
+// or its activate script for the environments that predate the config
+func isEnvironment(envPath string) bool {
+	for _, file := range []string{ConfigFile, filepath.Join("bin", "activate")} {
+		if _, err := os.Stat(filepath.Join(envPath, file)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// return back the name of the cached Go version linked into the environment
+func LinkedVersion(name string) (string, error) {
+	lib, err := os.Readlink(filepath.Join(cache.VenGO_PATH, name, "lib"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(lib), nil
+}
+
+// return back the last time the environment was activated, environments
+// that has never been activated report their creation time
+func LastActivated(name string) time.Time {
+	envPath := filepath.Join(cache.VenGO_PATH, name)
+	for _, file := range []string{activatedFile, filepath.Join("bin", "activate")} {
+		if info, err := os.Stat(filepath.Join(envPath, file)); err == nil {
+			return info.ModTime()
+		}
+	}
+	return time.Time{}
+}
+
+// link the given cached Go version into the environment replacing the
+// previously linked one
+func Relink(name, ver string) error {
+	library := filepath.Join(cache.VenGO_PATH, name, "lib")
+	if err := os.Remove(library); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Symlink(cache.VersionPath(ver), library); err != nil {
+		return err
+	}
+	c, err := LoadConfig(name)
+	if err != nil {
+		return err
+	}
+	c.GoVersion = ver
+	return c.Save(EnvironmentPath(name))
+}
+
+// return back the names of the environments linked to the given Go version
+func Dependents(ver string) ([]string, error) {
+	environments, err := Environments()
+	if err != nil {
+		return nil, err
+	}
+	dependents := []string{}
+	for _, name := range environments {
+		if linked, err := LinkedVersion(name); err == nil && linked == ver {
+			dependents = append(dependents, name)
+		}
+	}
+	return dependents, nil
+}
+
+// rewrite the paths of the given environment after the VenGO home or the
+// cache have been moved, the lib symlink, the configuration, the activate
+// scripts and the manifests are updated using the given replacer
+func RewritePaths(name string, replacer *cache.PathReplacer) error {
+	envPath := filepath.Join(cache.VenGO_PATH, name)
+	library := filepath.Join(envPath, "lib")
+	if link, err := os.Readlink(library); err == nil {
+		if relocated := replacer.Replace(link); relocated != link {
+			if err := os.Remove(library); err != nil {
+				return err
+			}
+			if err := os.Symlink(relocated, library); err != nil {
+				return err
+			}
+		}
+	}
+	files := []string{filepath.Join(envPath, ConfigFile)}
+	for _, pattern := range []string{"bin/*activate*", "*.manifest"} {
+		matches, err := filepath.Glob(filepath.Join(envPath, pattern))
+		if err != nil {
+			return err
+		}
+		files = append(files, matches...)
+	}
+	for _, file := range files {
+		if err := cache.RewritePaths(file, replacer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
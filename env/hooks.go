@@ -0,0 +1,82 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package env
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/DamnWidget/VenGO/cache"
+)
+
+// lifecycle hooks run by the activate scripts, the hook scripts are sourced
+// by the shell so they can modify its state, fish uses the .fish variants
+var Hooks = []string{
+	"preactivate", "postactivate", "predeactivate", "postdeactivate",
+}
+
+// check if the given name is a valid hook
+func IsHook(name string) bool {
+	for _, hook := range Hooks {
+		if hook == name {
+			return true
+		}
+	}
+	return false
+}
+
+// return back the path of the given global hook script, global hooks are
+// run for every environment
+func GlobalHookPath(hook string) string {
+	return filepath.Join(cache.VenGO_PATH, "hooks", hook)
+}
+
+// return back the path of the given global hook script, it is used by
+// the activate scripts templates
+func (e *Environment) GlobalHookPath(hook string) string {
+	return GlobalHookPath(hook)
+}
+
+// return back the path of the given hook script of the environment, it
+// lives in the environment hooks directory unless other path has been set
+func (e *Environment) HookPath(hook string) string {
+	if script, ok := e.Hooks[hook]; ok {
+		return script
+	}
+	return filepath.Join(e.VenGO_PATH, "hooks", hook)
+}
+
+// use the given script as the environment hook instead of the one in the
+// environment hooks directory, an empty script restores the default one
+func (e *Environment) SetHook(hook, script string) error {
+	if !IsHook(hook) {
+		return fmt.Errorf("%s is not a valid hook, use one of %v", hook, Hooks)
+	}
+	if script == "" {
+		delete(e.Hooks, hook)
+		return nil
+	}
+	if e.Hooks == nil {
+		e.Hooks = map[string]string{}
+	}
+	e.Hooks[hook] = script
+	return nil
+}
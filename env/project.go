@@ -0,0 +1,191 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// name of the marker file written into the directory of project environments
+const MarkerFile = ".vengo"
+
+// name of the file used by other Go version managers to pin the Go version
+// of a project, environments using that version apply to the project
+const GoVersionFile = ".go-version"
+
+// error returned when no marker file is found up the directory tree
+var ErrNoMarker = errors.New("no " + MarkerFile + " file found")
+
+// marker file structure, it names the environment used by a project
+type Marker struct {
+	Environment string `json:"environment"`
+	GoVersion   string `json:"go_version"`
+}
+
+// load the marker file in the given path
+func LoadMarker(path string) (*Marker, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Marker)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("malformed %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// write the marker file into the given directory
+func (m *Marker) Save(dir string) error {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, MarkerFile), data, 0644)
+}
+
+// look for the nearest marker file from the given directory up to the root
+// and return back its path, ErrNoMarker is returned if there is no one
+func FindMarker(dir string) (string, error) {
+	return findFile(dir, MarkerFile)
+}
+
+// return back the name of the environment that applies to the given
+// directory, the nearest marker or .go-version file up the directory tree
+// decides it, ErrNoMarker is returned if there is no one
+func ProjectEnvironment(dir string) (string, error) {
+	path, err := findFile(dir, MarkerFile, GoVersionFile)
+	if err != nil {
+		return "", err
+	}
+	if filepath.Base(path) == GoVersionFile {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) == 0 {
+			return "", fmt.Errorf("%s doesn't contain any Go version", path)
+		}
+		return VersionEnvironment(fields[0])
+	}
+	marker, err := LoadMarker(path)
+	if err != nil {
+		return "", err
+	}
+	if marker.Environment == "" {
+		return "", fmt.Errorf("%s doesn't name any environment", path)
+	}
+	return marker.Environment, nil
+}
+
+// return back the first environment that uses the given Go version, if no
+// one uses it exactly environments using its minor release, like go1.16 for
+// 1.16.3, are used. Project environments are skipped as their GOPATH belongs
+// to other project, the configuration is only loaded for the environments
+// that use a matching version
+func VersionEnvironment(ver string) (string, error) {
+	environments, err := Environments()
+	if err != nil {
+		return "", err
+	}
+	ver = strings.TrimPrefix(ver, "go")
+	minor := ""
+	for _, name := range environments {
+		var c *Config
+		linked, err := LinkedVersion(name)
+		if err != nil {
+			if c, err = LoadConfig(name); err != nil {
+				continue
+			}
+			linked = c.GoVersion
+		}
+		linked = strings.TrimPrefix(linked, "go")
+		exact := linked == ver
+		if !exact && (minor != "" || linked == "" ||
+			!strings.HasPrefix(ver, linked+".")) {
+			continue
+		}
+		if c == nil {
+			if c, err = LoadConfig(name); err != nil {
+				continue
+			}
+		}
+		if c.Project != "" {
+			continue
+		}
+		if exact {
+			return name, nil
+		}
+		minor = name
+	}
+	if minor != "" {
+		return minor, nil
+	}
+	return "", fmt.Errorf("there is no environment using Go %s", ver)
+}
+
+// look for the nearest of the given files from the directory up to the
+// root, files are checked in order in every directory
+func findFile(dir string, names ...string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrNoMarker
+		}
+		dir = parent
+	}
+}
+
+// use the given project directory as the environment GOPATH
+func (e *Environment) SetProject(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	e.Project = dir
+	e.Gopath = dir
+	return nil
+}
+
+// write the marker file of the environment into its project directory
+func (e *Environment) WriteMarker() error {
+	if e.Project == "" {
+		return fmt.Errorf("%s is not a project environment", e.Name)
+	}
+	return (&Marker{Environment: e.Name, GoVersion: e.GoVersion}).Save(e.Project)
+}
@@ -0,0 +1,189 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package env
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// shell backend structure, every backend generates its scripts from the
+// templates with the same name into the environment bin directory
+type shellType struct {
+	name    string
+	scripts []string
+	hooks   bool
+	hookExt string
+	quote   func(string) string
+}
+
+// POSIX sh and bash, zsh can use it too
+var bashShell = &shellType{
+	name:    "bash",
+	scripts: []string{"activate"},
+	hooks:   true,
+	quote:   QuotePosix,
+}
+
+// zsh native script, the prompt is set from a precmd hook
+var zshShell = &shellType{
+	name:    "zsh",
+	scripts: []string{"activate.zsh"},
+	hooks:   true,
+	quote:   QuotePosix,
+}
+
+// fish
+var fishShell = &shellType{
+	name:    "fish",
+	scripts: []string{"activate.fish"},
+	hooks:   true,
+	hookExt: ".fish",
+	quote:   QuoteFish,
+}
+
+// csh and tcsh, they have no functions so deactivate is an alias that
+// sources its own script
+var cshShell = &shellType{
+	name:    "csh",
+	scripts: []string{"activate.csh", "deactivate.csh"},
+	hooks:   true,
+	hookExt: ".csh",
+	quote:   quoteCsh,
+}
+
+// nushell, the script is an overlay so hooks can't be sourced from it
+var nuShell = &shellType{
+	name:    "nu",
+	scripts: []string{"activate.nu"},
+	quote:   quoteNu,
+}
+
+// PowerShell, also usable with pwsh on Linux and macOS
+var powershellShell = &shellType{
+	name:    "powershell",
+	scripts: []string{"activate.ps1"},
+	hooks:   true,
+	hookExt: ".ps1",
+	quote:   quotePowerShell,
+}
+
+var shellTypes = []*shellType{
+	bashShell, zshShell, fishShell, cshShell, nuShell, powershellShell,
+}
+
+// return back the names of the supported shells
+func Shells() []string {
+	names := []string{}
+	for _, shell := range shellTypes {
+		names = append(names, shell.name)
+	}
+	return names
+}
+
+// return back the shell backend with the given name
+func lookupShell(name string) (*shellType, error) {
+	for _, shell := range shellTypes {
+		if shell.name == name {
+			return shell, nil
+		}
+	}
+	return nil, fmt.Errorf(
+		"%s is not a supported shell, use one of %s",
+		name, strings.Join(Shells(), ", "))
+}
+
+// return back the extension of the hook scripts for the given shell, it
+// fails with shells that can't run hooks
+func HookExtension(name string) (string, error) {
+	shell, err := lookupShell(name)
+	if err != nil {
+		return "", err
+	}
+	if !shell.hooks {
+		return "", fmt.Errorf("%s doesn't support hooks", name)
+	}
+	return shell.hookExt, nil
+}
+
+// return back the extensions of the hook scripts of every shell
+func HookExtensions() []string {
+	extensions := []string{}
+	seen := map[string]bool{}
+	for _, shell := range shellTypes {
+		if shell.hooks && !seen[shell.hookExt] {
+			seen[shell.hookExt] = true
+			extensions = append(extensions, shell.hookExt)
+		}
+	}
+	return extensions
+}
+
+// data used to execute the activate scripts templates
+type activateData struct {
+	*Environment
+	shell *shellType
+}
+
+// return back the environment hook script for the template shell
+func (d *activateData) Hook(hook string) string {
+	return d.HookPath(hook) + d.shell.hookExt
+}
+
+// return back the global hook script for the template shell
+func (d *activateData) GlobalHook(hook string) string {
+	return GlobalHookPath(hook) + d.shell.hookExt
+}
+
+// return back the path of the given script in the environment bin directory
+func (d *activateData) Script(name string) string {
+	return filepath.Join(d.VenGO_PATH, "bin", name)
+}
+
+// quote a string for POSIX shells, the hook-shell command uses it too
+func QuotePosix(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
+
+// quote a string for fish, the hook-shell command uses it too
+func QuoteFish(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// quote a string for csh and tcsh, history substitutions are escaped too
+func quoteCsh(s string) string {
+	r := strings.NewReplacer("'", `'\''`, "!", `\!`)
+	return "'" + r.Replace(s) + "'"
+}
+
+// quote a string for nushell
+func quoteNu(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// quote a string for PowerShell
+func quotePowerShell(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package env
+
+import (
+	"embed"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/DamnWidget/VenGO/cache"
+)
+
+// default activate scripts templates built into the binary
+//
+//go:embed tpl
+var defaultTemplates embed.FS
+
+// return back the directory where the users can override the default
+// activate scripts templates
+func TemplatesDirectory() string {
+	return filepath.Join(cache.VenGO_PATH, "templates")
+}
+
+// return back the names of the activate scripts templates of every shell
+func Templates() []string {
+	names := []string{}
+	for _, shell := range shellTypes {
+		names = append(names, shell.scripts...)
+	}
+	return names
+}
+
+// return back the default version of the given template
+func DefaultTemplate(name string) ([]byte, error) {
+	return defaultTemplates.ReadFile(path.Join("tpl", name))
+}
+
+// return back the given template, the one in the templates directory if
+// the user has overridden it or the default one otherwise
+func readTemplate(name string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(TemplatesDirectory(), name))
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return DefaultTemplate(name)
+}
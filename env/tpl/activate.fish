@@ -4,10 +4,21 @@
 # This script is inspired by virtualenv for Python written by
 # Jannis Leidel, Carl Meyer and Brian Rosner
 
+# source the given hook script if it exists
+function _vengo_run_hook
+    if test -f "$argv[1]"
+        source "$argv[1]"
+    end
+end
+
 function deactivate --description "Deactivate a VenGO active environment"
     if not set -q VENGO_ENV
         return 0
     end
+    if test "$argv[1]" != "just_reset"
+        _vengo_run_hook {{ quote (.Hook "predeactivate") }}
+        _vengo_run_hook {{ quote (.GlobalHook "predeactivate") }}
+    end
     # reset environment variables
     set -x PATH $PATH[3..(count $PATH)]
     if test -n "$_VENGO_PREV_PATH"
@@ -26,6 +37,17 @@ function deactivate --description "Deactivate a VenGO active environment"
         set -g GOPATH "$_VENGO_PREV_GOPATH"
         set -e _VENGO_PREV_GOPATH
     end
+{{- range $name, $value := .Env }}
+    if set -q _VENGO_SET_{{ $name }}
+        if set -q _VENGO_PREV_{{ $name }}
+            set -gx {{ $name }} $_VENGO_PREV_{{ $name }}
+            set -e _VENGO_PREV_{{ $name }}
+        else
+            set -e {{ $name }}
+        end
+        set -e _VENGO_SET_{{ $name }}
+    end
+{{- end }}
 
     # set an empty local fish_function_path, so fish_prompt doesn't automatically reload
     set -l fish_function_path
@@ -36,7 +58,9 @@ function deactivate --description "Deactivate a VenGO active environment"
     set -e VENGO_ENV
     set -e VENGO_PROMPT
     if test "$argv[1]" != "just_reset"
-        functions -e deactivate
+        _vengo_run_hook {{ quote (.Hook "postdeactivate") }}
+        _vengo_run_hook {{ quote (.GlobalHook "postdeactivate") }}
+        functions -e deactivate _vengo_run_hook
     end
 end
 
@@ -45,8 +69,12 @@ end
 # environment is still active for
 deactivate just_reset
 
+# run the preactivate hooks
+_vengo_run_hook {{ quote (.GlobalHook "preactivate") }}
+_vengo_run_hook {{ quote (.Hook "preactivate") }}
+
 # set paths
-set -g VENGO_ENV "{{ .VenGO_PATH }}"
+set -g VENGO_ENV {{ quote .VenGO_PATH }}
 set -x VENGO_ENV $VENGO_ENV
 # unset and backup old configuration
 set -g _VENGO_PREV_GOROOT (go env GOROOT)
@@ -59,11 +87,18 @@ set -g _VENGO_PREV_GOPATH (go env GOPATH)
 set -e GOPATH
 
 # set new environment variables
-set -g GOROOT "{{ .Goroot }}"
-set -g GOTOOLDIR "{{ .Gotooldir }}"
-set -g GOPATH "{{ .Gopath }}"
+set -g GOROOT {{ quote .Goroot }}
+set -g GOTOOLDIR {{ quote .Gotooldir }}
+set -g GOPATH {{ quote .Gopath }}
 set -x GOPATH $GOPATH
-set -g VENGO_PROMPT "{{ .PS1 }}"
+set -g VENGO_PROMPT {{ quote .PS1 }}
+{{ range $name, $value := .Env -}}
+if set -q {{ $name }}
+    set -g _VENGO_PREV_{{ $name }} ${{ $name }}
+end
+set -g _VENGO_SET_{{ $name }} 1
+set -gx {{ $name }} {{ quote $value }}
+{{ end -}}
 
 # set the PATH
 set -g PATH "$GOROOT/bin" "$GOPATH/bin" $PATH
@@ -82,3 +117,10 @@ function fish_prompt
         _vengo_fish_prompt
     end
 end
+
+# record the activation so unused Go versions can be archived
+touch "$VENGO_ENV/.vengo-activated" 2>/dev/null
+
+# run the postactivate hooks
+_vengo_run_hook {{ quote (.GlobalHook "postactivate") }}
+_vengo_run_hook {{ quote (.Hook "postactivate") }}
@@ -0,0 +1,108 @@
+# This file can't be executed directly, it has to be
+# dot sourced with '. activate.ps1' from PowerShell or pwsh
+
+# This script is inspired by virtualenv for Python written by
+# Jannis Leidel, Carl Meyer and Brian Rosner
+
+# dot source the given hook script if it exists
+function global:_vengo_run_hook([string] $Hook) {
+    if (Test-Path -LiteralPath $Hook -PathType Leaf) {
+        . $Hook
+    }
+}
+
+# restore the given environment variable from its backup
+function global:_vengo_restore([string] $Name, [string] $Backup) {
+    if (Test-Path "env:$Backup") {
+        Set-Item "env:$Name" (Get-Item "env:$Backup").Value
+        Remove-Item "env:$Backup"
+    } elseif (Test-Path "env:$Name") {
+        Remove-Item "env:$Name"
+    }
+}
+
+function global:deactivate([switch] $JustReset) {
+    if (-not $JustReset) {
+        _vengo_run_hook {{ quote (.Hook "predeactivate") }}
+        _vengo_run_hook {{ quote (.GlobalHook "predeactivate") }}
+    }
+
+    # reset environment variables
+    if (Test-Path env:_VENGO_PREV_PATH) {
+        _vengo_restore PATH _VENGO_PREV_PATH
+        _vengo_restore GOROOT _VENGO_PREV_GOROOT
+        _vengo_restore GOTOOLDIR _VENGO_PREV_GOTOOLDIR
+        _vengo_restore GOPATH _VENGO_PREV_GOPATH
+    }
+{{- range $name, $value := .Env }}
+    if (Test-Path env:_VENGO_SET_{{ $name }}) {
+        _vengo_restore {{ $name }} _VENGO_PREV_{{ $name }}
+        Remove-Item env:_VENGO_SET_{{ $name }}
+    }
+{{- end }}
+
+    # restore prompt
+    if (Test-Path function:_vengo_prev_prompt) {
+        $function:prompt = $function:_vengo_prev_prompt
+        Remove-Item function:_vengo_prev_prompt
+    }
+
+    if (Test-Path env:VENGO_ENV) {
+        Remove-Item env:VENGO_ENV
+    }
+    if (-not $JustReset) {
+        _vengo_run_hook {{ quote (.Hook "postdeactivate") }}
+        _vengo_run_hook {{ quote (.GlobalHook "postdeactivate") }}
+        Remove-Item function:deactivate, function:_vengo_run_hook, function:_vengo_restore
+    }
+}
+
+# reset environment
+# this is useful if someone activate an environment while other
+# environment is still active for
+deactivate -JustReset
+
+# run the preactivate hooks
+_vengo_run_hook {{ quote (.GlobalHook "preactivate") }}
+_vengo_run_hook {{ quote (.Hook "preactivate") }}
+
+# set paths
+$env:VENGO_ENV = {{ quote .VenGO_PATH }}
+# unset and backup old configuration
+$env:_VENGO_PREV_GOROOT = (go env GOROOT)
+$env:_VENGO_PREV_GOTOOLDIR = (go env GOTOOLDIR)
+$env:_VENGO_PREV_GOPATH = (go env GOPATH)
+
+# set new environment variables
+$env:GOROOT = {{ quote .Goroot }}
+$env:GOTOOLDIR = {{ quote .Gotooldir }}
+$env:GOPATH = {{ quote .Gopath }}
+
+{{ range $name, $value := .Env -}}
+if (Test-Path env:{{ $name }}) {
+    $env:_VENGO_PREV_{{ $name }} = $env:{{ $name }}
+}
+$env:_VENGO_SET_{{ $name }} = '1'
+$env:{{ $name }} = {{ quote $value }}
+
+{{ end -}}
+$env:_VENGO_PREV_PATH = $env:PATH
+$env:PATH = @(
+    (Join-Path $env:GOROOT 'bin'), (Join-Path $env:GOPATH 'bin'), $env:PATH
+) -join [IO.Path]::PathSeparator
+
+if (-not (Test-Path env:VENGO_ENV_DISABLE_PROMPT)) {
+    function global:_vengo_prev_prompt { "" }
+    $function:_vengo_prev_prompt = $function:prompt
+    function global:prompt {
+        Write-Host -NoNewline ({{ quote .PS1 }} + ' ')
+        & $function:_vengo_prev_prompt
+    }
+}
+
+# record the activation so unused Go versions can be archived
+New-Item -ItemType File -Force (Join-Path $env:VENGO_ENV '.vengo-activated') | Out-Null
+
+# run the postactivate hooks
+_vengo_run_hook {{ quote (.GlobalHook "postactivate") }}
+_vengo_run_hook {{ quote (.Hook "postactivate") }}
@@ -21,6 +21,8 @@
 # tools
 GO=`which go`
 GIT=`which git`
+GPG=`which gpg`
+CURL=`which curl`
 
 # colors
 OK="\033[32m"
@@ -32,6 +34,8 @@ CURRDIR=`pwd`
 WORKDIR='.vengo_installation'
 DESTDIR=$HOME/.VenGO
 REPOSITORY='github.com/DamnWidget/VenGO'
+# key used to sign the Go release archives
+SIGNING_KEY='https://dl.google.com/linux/linux_signing_key.pub'
 
 
 [ "$GIT" = "" ] && {
@@ -66,11 +70,29 @@ fi
 rm -Rf "${DESTDIR}/bin"
 rm -Rf "${DESTDIR}/scripts/tpl"
 mv $WORKDIR/bin $DESTDIR/
-mv $WORKDIR/env/tpl $DESTDIR/scripts/
 mv $WORKDIR/VERSION $DESTDIR/
 rm -Rf $WORKDIR
 echo -e "${OK}✔${RESET}"
 
+echo -n "Bundling the Go releases signing keyring... "
+if [ "$GPG" = "" ]; then
+    echo -e "${FAIL}✖${RESET} gpg is not installed, signatures will not be verified"
+else
+    mkdir -p $DESTDIR/share
+    if [ "$CURL" != "" ]; then
+        FETCH="$CURL -fsSL $SIGNING_KEY"
+    else
+        FETCH="wget -qO- $SIGNING_KEY"
+    fi
+    $FETCH | $GPG --dearmor > $DESTDIR/share/keyring.gpg.tmp 2> /dev/null && \
+        [ -s $DESTDIR/share/keyring.gpg.tmp ] && \
+        mv $DESTDIR/share/keyring.gpg.tmp $DESTDIR/share/keyring.gpg && \
+        echo -e "${OK}✔${RESET}" || {
+            rm -f $DESTDIR/share/keyring.gpg.tmp
+            echo -e "${FAIL}✖${RESET} the signing key can't be downloaded"
+        }
+fi
+
 
 echo ""
 echo -e "${OK}VenGO is now installed in your system${RESET}"
@@ -0,0 +1,284 @@
+/*
+   Copyright (C) 2014  Oscar Campos <oscar.campos@member.fsf.org>
+
+   This program is free software; you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation; either version 2 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License along
+   with this program; if not, write to the Free Software Foundation, Inc.,
+   51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+
+   See LICENSE file for more details.
+*/
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DamnWidget/VenGO/utils"
+)
+
+// logging levels
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+// return back the name of the level
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	case Warn:
+		return "warn"
+	}
+	return "error"
+}
+
+// output formats
+const (
+	Text = iota
+	Json
+)
+
+// structured event emitted by the logger
+type Event struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Logger is a leveled and structured logger that writes events to a writer
+// in human readable text or in JSON (one object per line) format, a nil
+// Logger discards all the events
+type Logger struct {
+	Level  Level
+	Format int
+	Writer io.Writer
+
+	fields map[string]interface{}
+	mu     *sync.Mutex
+}
+
+// Create a new logger writing Info and above text events to the standard
+// output and return back it's address
+func New(options ...func(l *Logger)) *Logger {
+	logger := &Logger{Level: Info, Format: Text, Writer: os.Stdout}
+	for _, option := range options {
+		option(logger)
+	}
+	logger.mu = new(sync.Mutex)
+	return logger
+}
+
+// create a logger that discards all the events
+func Discard() *Logger {
+	return New(func(l *Logger) { l.Writer = ioutil.Discard })
+}
+
+// return back a copy of the logger that adds the given key value pairs to
+// every event
+func (l *Logger) With(kv ...interface{}) *Logger {
+	if l == nil {
+		return nil
+	}
+	child := *l
+	child.fields = l.merge(kv)
+	return &child
+}
+
+// emit a debug event
+func (l *Logger) Debug(msg string, kv ...interface{}) {
+	l.emit(Debug, msg, kv)
+}
+
+// emit an info event
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	l.emit(Info, msg, kv)
+}
+
+// emit a warning event
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	l.emit(Warn, msg, kv)
+}
+
+// emit an error event
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	l.emit(Error, msg, kv)
+}
+
+// Step is an operation in progress, in text format it is shown as the
+// message followed by a mark when the operation is done
+type Step struct {
+	logger  *Logger
+	message string
+	fields  []interface{}
+}
+
+// start a new step
+func (l *Logger) Step(msg string, kv ...interface{}) *Step {
+	if l.enabled(Info) && l.Format == Text {
+		l.mu.Lock()
+		fmt.Fprintf(l.Writer, "%s... ", msg)
+		l.mu.Unlock()
+	}
+	return &Step{l, msg, kv}
+}
+
+// finish the step successfully
+func (s *Step) Ok(kv ...interface{}) {
+	if s.logger == nil {
+		return
+	}
+	if s.logger.Format == Text {
+		if s.logger.enabled(Info) {
+			s.logger.mu.Lock()
+			fmt.Fprintln(s.logger.Writer, utils.Ok("✔"))
+			s.logger.mu.Unlock()
+		}
+		return
+	}
+	s.logger.emit(Info, s.message, append(append(s.fields, "status", "ok"), kv...))
+}
+
+// finish the step with the given error (that can be nil)
+func (s *Step) Fail(err error, kv ...interface{}) {
+	if s.logger == nil {
+		return
+	}
+	if s.logger.Format == Text {
+		if s.logger.enabled(Info) {
+			s.logger.mu.Lock()
+			fmt.Fprintln(s.logger.Writer, utils.Fail("✖"))
+			s.logger.mu.Unlock()
+		} else if err != nil {
+			s.logger.Error(fmt.Sprintf("%s: %v", s.message, err))
+		}
+		return
+	}
+	kv = append(append(s.fields, "status", "failed"), kv...)
+	if err != nil {
+		kv = append(kv, "error", err.Error())
+	}
+	s.logger.emit(Error, s.message, kv)
+}
+
+// Writer that emits every written line as an event of the given level,
+// it is used to route the output of external commands
+func (l *Logger) LineWriter(level Level) io.Writer {
+	if l == nil {
+		return ioutil.Discard
+	}
+	return &lineWriter{logger: l, level: level}
+}
+
+type lineWriter struct {
+	logger *Logger
+	level  Level
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		n := strings.IndexByte(string(w.buf), '\n')
+		if n < 0 {
+			return len(p), nil
+		}
+		w.logger.emit(w.level, string(w.buf[:n]), nil)
+		w.buf = w.buf[n+1:]
+	}
+}
+
+// checks if the given level is enabled
+func (l *Logger) enabled(level Level) bool {
+	return l != nil && level >= l.Level
+}
+
+// merge the logger fields with the given key value pairs
+func (l *Logger) merge(kv []interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields[fmt.Sprint(kv[i])] = kv[i+1]
+	}
+	return fields
+}
+
+// write the event in the configured format
+func (l *Logger) emit(level Level, msg string, kv []interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	event := &Event{time.Now(), level, msg, l.merge(kv)}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.Format == Json {
+		l.writeJson(event)
+		return
+	}
+	l.writeText(event)
+}
+
+func (l *Logger) writeJson(e *Event) {
+	data := map[string]interface{}{}
+	for k, v := range e.Fields {
+		data[k] = v
+	}
+	data["time"] = e.Time.Format(time.RFC3339)
+	data["level"] = e.Level.String()
+	data["msg"] = e.Message
+	out, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(l.Writer, string(out))
+}
+
+// in text format the fields are only shown when debugging so the regular
+// output stays readable
+func (l *Logger) writeText(e *Event) {
+	msg := e.Message
+	switch e.Level {
+	case Warn:
+		msg = fmt.Sprintf("%s: %s", utils.Fail("warning"), msg)
+	case Error:
+		msg = utils.Fail(msg)
+	}
+	keys := []string{}
+	for k := range e.Fields {
+		if l.Level == Debug {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		msg = fmt.Sprintf("%s %s=%v", msg, k, e.Fields[k])
+	}
+	fmt.Fprintln(l.Writer, msg)
+}
@@ -21,19 +21,30 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 
+	"github.com/DamnWidget/VenGO/cache"
 	"github.com/DamnWidget/VenGO/commands"
+	"github.com/DamnWidget/VenGO/utils"
 )
 
 // Main application entry point
 func main() {
-	flag.Usage = commands.Usage
+	quiet := flag.Bool("quiet", false, "only show errors")
+	verbose := flag.Bool("verbose", false, "show debug output")
+	logFormat := flag.String("log-format", "text", "log format, text or json")
+	flag.Usage = func() { commands.Usage() }
 	flag.Parse()
+	log, err := commands.NewLogger(*quiet, *verbose, *logFormat)
+	if err != nil {
+		fail(err)
+	}
 	args := flag.Args()
 	if len(args) < 1 {
-		commands.Usage()
+		fail(commands.Usage())
 	}
 
 	if args[0] == "version" {
@@ -42,17 +53,53 @@ func main() {
 	}
 
 	if args[0] == "help" {
-		commands.Help(args[1:]...)
+		if err := commands.Help(args[1:]...); err != nil {
+			fail(err)
+		}
 		return
 	}
 
 	cmd, ok := commands.Commands[args[0]]
 	if !ok {
 		commands.NonCommand(args[0])
-		os.Exit(2)
+		fail(commands.ErrUsage)
+	}
+	cmd.Log = log
+	// the flags parsing error is reported by the flag set itself
+	cmd.Flag.Usage = func() {}
+	err = cmd.Flag.Parse(args[1:])
+	if err != nil {
+		err = fmt.Errorf("%v: %w", err, commands.ErrUsage)
+	} else {
+		err = cmd.Execute(cmd, cmd.Flag.Args()...)
+	}
+	if err != nil {
+		cmd.DisplayError(err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// display the error if it has not been reported yet and exit with its
+// exit code, it's used for the errors that don't come from a command
+func fail(err error) {
+	if !errors.Is(err, commands.ErrUsage) {
+		fmt.Println(utils.Fail(fmt.Sprintf("error: %v", err)))
+	}
+	os.Exit(exitCode(err))
+}
+
+// return back the exit code for the error returned by a command, commands
+// never exit the process by themselves so this is the only place where the
+// exit codes are decided, commands that run other programs return theirs
+func exitCode(err error) int {
+	var exit *commands.ExitError
+	switch {
+	case errors.As(err, &exit):
+		return exit.Code
+	case err == nil:
+		return 0
+	case errors.Is(err, cache.ErrGitMissing):
+		return 3
 	}
-	cmd.Flag.Usage = func() { cmd.DisplayUsageAndExit() }
-	cmd.Flag.Parse(args[1:])
-	cmd.Execute(cmd, cmd.Flag.Args()...)
-	return
+	return 2
 }
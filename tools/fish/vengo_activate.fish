@@ -10,18 +10,25 @@ function vengo_activate --description 'Activate a VenGO virtual environment'
                 set -g __NORMAL (set_color normal)
         end
 
-        if count $argv >/dev/null
-                set environment "$VENGO_HOME/$argv[1]/bin/activate.fish"
-                if test -e "$environment"
-                        . $environment
-                        return 0
-                else
-                        echo "VenGO: Environment '$VENGO_HOME/$environment' doesn't  iexists." >&2
-            echo -n -s "  " "$__OK" "suggestion" "$__NORMAL" ": check the integrity of the environments with 'vengo lsenvs'" >&2
+        if not count $argv >/dev/null
+                # use the environment of the nearest .vengo file
+                set -l project ("$VENGO_HOME/bin/vengo" which); or set project ""
+                if test -z "$project"
+                        vengo lsenvs
+                        return 2
                 end
+                set argv $project
+        end
+
+        set environment "$VENGO_HOME/$argv[1]/bin/activate.fish"
+        if test -e "$environment"
+                # restore the environment Go version if it has been archived
+                "$VENGO_HOME/bin/vengo" cache restore -e $argv[1] >/dev/null; or return 1
+                . $environment
+                return 0
         else
-                vengo lsenvs
-                return 2
+                echo "VenGO: Environment '$VENGO_HOME/$environment' doesn't  iexists." >&2
+                echo -n -s "  " "$__OK" "suggestion" "$__NORMAL" ": check the integrity of the environments with 'vengo lsenvs'" >&2
         end
 end
 
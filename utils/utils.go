@@ -23,6 +23,7 @@ package utils
 import (
 	"bufio"
 	"fmt"
+	"os"
 	"os/exec"
 )
 
@@ -53,8 +54,18 @@ func LogOperation(readers []*bufio.Reader) {
 
 // execute an external command and update the output as it's being written
 func Exec(verbose bool, args ...string) error {
+	return ExecIn("", nil, verbose, args...)
+}
+
+// execute an external command in the given directory adding the given
+// variables to the environment, the process working directory and
+// environment are never modified
+func ExecIn(dir string, env []string, verbose bool, args ...string) error {
 	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Env = nil
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	out, err := cmd.StdoutPipe()
 	if err != nil {
 		return err